@@ -0,0 +1,183 @@
+package recaptcha
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type AdaptiveThreshold struct {
+	mutex    sync.Mutex
+	minimum  float32
+	maximum  float32
+	lowScore float32
+	baseline float64
+	samples  int
+	window   time.Duration
+	slots    int
+	actions  map[string][]scoreSlot
+	tracked  *labelSet
+	now      func() time.Time
+}
+
+func NewAdaptiveThreshold(minimum, maximum float32, options ...AdaptiveOption) *AdaptiveThreshold {
+	this := &AdaptiveThreshold{
+		minimum: minimum,
+		maximum: maximum,
+		actions: make(map[string][]scoreSlot),
+		tracked: newLabelSet(defaultLabelLimit),
+		now:     time.Now,
+	}
+
+	WithLowScore(defaultLowScore)(this)
+	WithBaselineLowShare(defaultBaselineLowShare)(this)
+	WithMinimumSamples(defaultMinimumSamples)(this)
+	WithAdaptiveWindow(defaultAdaptiveWindow, defaultAdaptiveSlots)(this)
+
+	for _, option := range options {
+		option(this)
+	}
+
+	return this
+}
+
+func (this *AdaptiveThreshold) Record(action string, score float32, accepted bool) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	slot := this.currentSlot(this.tracked.fold(action))
+	slot.total++
+	if score < this.lowScore {
+		slot.low++
+	}
+	if !accepted {
+		slot.rejected++
+	}
+}
+func (this *AdaptiveThreshold) currentSlot(action string) *scoreSlot {
+	slots, found := this.actions[action]
+	if !found {
+		slots = make([]scoreSlot, this.slots)
+		this.actions[action] = slots
+	}
+
+	epoch := this.epoch()
+	slot := &slots[epoch%int64(this.slots)]
+	if slot.epoch != epoch {
+		*slot = scoreSlot{epoch: epoch}
+	}
+
+	return slot
+}
+
+func (this *AdaptiveThreshold) Threshold(action string) float32 {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.stats(this.tracked.fold(action)).Threshold
+}
+
+func (this *AdaptiveThreshold) Snapshot() map[string]AdaptiveStats {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	snapshot := make(map[string]AdaptiveStats, len(this.actions))
+	for action := range this.actions {
+		snapshot[action] = this.stats(action)
+	}
+	return snapshot
+}
+
+func (this *AdaptiveThreshold) ServeHTTP(response http.ResponseWriter, _ *http.Request) {
+	response.Header().Set(contentTypeHeader, jsonContentType)
+	_ = json.NewEncoder(response).Encode(this.Snapshot())
+}
+
+func (this *AdaptiveThreshold) stats(action string) (stats AdaptiveStats) {
+	oldest := this.epoch() - int64(this.slots) + 1
+	var low, rejected int
+
+	for _, slot := range this.actions[action] {
+		if slot.epoch >= oldest {
+			stats.Samples += slot.total
+			low += slot.low
+			rejected += slot.rejected
+		}
+	}
+
+	stats.Threshold = this.minimum
+	if stats.Samples == 0 {
+		return stats
+	}
+
+	stats.LowShare = float64(low) / float64(stats.Samples)
+	stats.RejectionRate = float64(rejected) / float64(stats.Samples)
+
+	if stats.Samples >= this.samples {
+		stats.Threshold = this.scale(stats.LowShare)
+	}
+
+	return stats
+}
+func (this *AdaptiveThreshold) scale(lowShare float64) float32 {
+	excess := (lowShare - this.baseline) / (1 - this.baseline)
+	excess = math.Max(0, math.Min(1, excess))
+	return this.minimum + float32(excess)*(this.maximum-this.minimum)
+}
+func (this *AdaptiveThreshold) epoch() int64 {
+	return this.now().UnixNano() / int64(this.window/time.Duration(this.slots))
+}
+
+type AdaptiveStats struct {
+	Threshold     float32 `json:"threshold"`
+	Samples       int     `json:"samples"`
+	LowShare      float64 `json:"low_share"`
+	RejectionRate float64 `json:"rejection_rate"`
+}
+
+type scoreSlot struct {
+	epoch    int64
+	total    int
+	low      int
+	rejected int
+}
+
+/* ------------------------------------------------------------------------------------------------------------------ */
+
+type AdaptiveOption func(*AdaptiveThreshold)
+
+func WithLowScore(value float32) AdaptiveOption {
+	return func(this *AdaptiveThreshold) { this.lowScore = value }
+}
+func WithBaselineLowShare(value float64) AdaptiveOption {
+	if !(value >= 0 && value < 1) {
+		panic(fmt.Errorf("%w: baseline low share %v must be at least 0 and below 1", errBadOptionProvided, value))
+	}
+
+	return func(this *AdaptiveThreshold) { this.baseline = value }
+}
+func WithMinimumSamples(value int) AdaptiveOption {
+	return func(this *AdaptiveThreshold) { this.samples = value }
+}
+func WithAdaptiveWindow(window time.Duration, slots int) AdaptiveOption {
+	if slots < 1 || window < time.Duration(slots) {
+		panic(fmt.Errorf("%w: adaptive window %s cannot be divided into %d slots", errBadOptionProvided, window, slots))
+	}
+
+	return func(this *AdaptiveThreshold) { this.window = window; this.slots = slots }
+}
+
+func WithAdaptiveActions(values ...string) AdaptiveOption {
+	allowed := labelAllowlist(values)
+	return func(this *AdaptiveThreshold) { this.tracked.allowed = allowed }
+}
+
+const (
+	defaultLowScore         = 0.3
+	defaultBaselineLowShare = 0.1
+	defaultMinimumSamples   = 50
+	defaultAdaptiveWindow   = time.Minute * 10
+	defaultAdaptiveSlots    = 10
+)
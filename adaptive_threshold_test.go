@@ -0,0 +1,141 @@
+package recaptcha
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+)
+
+func TestAdaptiveThresholdFixture(t *testing.T) {
+	gunit.Run(new(AdaptiveThresholdFixture), t)
+}
+
+type AdaptiveThresholdFixture struct {
+	*gunit.Fixture
+
+	now       time.Time
+	threshold *AdaptiveThreshold
+}
+
+func (this *AdaptiveThresholdFixture) Setup() {
+	this.now = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	this.threshold = NewAdaptiveThreshold(0.3, 0.7,
+		WithLowScore(0.3),
+		WithBaselineLowShare(0.2),
+		WithMinimumSamples(10),
+		WithAdaptiveWindow(time.Minute, 6))
+	this.threshold.now = func() time.Time { return this.now }
+}
+
+func (this *AdaptiveThresholdFixture) TestMinimumWithoutTraffic() {
+	this.So(this.threshold.Threshold("login"), should.Equal, float32(0.3))
+}
+func (this *AdaptiveThresholdFixture) TestMinimumUntilEnoughSamples() {
+	this.record("login", 9, 0.1)
+
+	this.So(this.threshold.Threshold("login"), should.Equal, float32(0.3))
+}
+func (this *AdaptiveThresholdFixture) TestMinimumWhenLowShareAtBaseline() {
+	this.record("login", 8, 0.9)
+	this.record("login", 2, 0.1)
+
+	this.So(this.threshold.Threshold("login"), should.Equal, float32(0.3))
+}
+func (this *AdaptiveThresholdFixture) TestRaisedProportionallyWhenLowScoresSpike() {
+	this.record("login", 4, 0.9)
+	this.record("login", 6, 0.1)
+
+	this.So(this.threshold.Threshold("login"), should.AlmostEqual, 0.5, 0.0001)
+}
+func (this *AdaptiveThresholdFixture) TestCappedAtMaximum() {
+	this.record("login", 20, 0.1)
+
+	this.So(this.threshold.Threshold("login"), should.AlmostEqual, 0.7, 0.0001)
+}
+func (this *AdaptiveThresholdFixture) TestTrackedPerAction() {
+	this.record("login", 20, 0.1)
+	this.record("signup", 20, 0.9)
+
+	this.So(this.threshold.Threshold("login"), should.AlmostEqual, 0.7, 0.0001)
+	this.So(this.threshold.Threshold("signup"), should.Equal, float32(0.3))
+}
+func (this *AdaptiveThresholdFixture) TestRelaxesAsSpikeLeavesWindow() {
+	this.record("login", 20, 0.1)
+	this.now = this.now.Add(time.Second * 30)
+	this.record("login", 20, 0.9)
+
+	this.So(this.threshold.Threshold("login"), should.AlmostEqual, 0.45, 0.0001)
+
+	this.now = this.now.Add(time.Second * 40)
+
+	this.So(this.threshold.Threshold("login"), should.Equal, float32(0.3))
+}
+func (this *AdaptiveThresholdFixture) TestSnapshot() {
+	this.threshold.Record("login", 0.1, false)
+	this.threshold.Record("login", 0.9, true)
+
+	snapshot := this.threshold.Snapshot()
+
+	this.So(snapshot, should.Resemble, map[string]AdaptiveStats{
+		"login": {Threshold: 0.3, Samples: 2, LowShare: 0.5, RejectionRate: 0.5},
+	})
+}
+func (this *AdaptiveThresholdFixture) TestServeHTTP() {
+	this.threshold.Record("login", 0.1, false)
+	response := httptest.NewRecorder()
+
+	this.threshold.ServeHTTP(response, httptest.NewRequest("GET", "/", nil))
+
+	var snapshot map[string]AdaptiveStats
+	this.So(json.Unmarshal(response.Body.Bytes(), &snapshot), should.BeNil)
+	this.So(response.Header().Get(contentTypeHeader), should.Equal, jsonContentType)
+	this.So(snapshot["login"].Samples, should.Equal, 1)
+	this.So(snapshot["login"].RejectionRate, should.Equal, 1.0)
+}
+
+func (this *AdaptiveThresholdFixture) TestUnlistedActionsShareOtherSlot() {
+	WithAdaptiveActions("login")(this.threshold)
+	this.record("random-1", 10, 0.1)
+	this.record("random-2", 10, 0.1)
+
+	snapshot := this.threshold.Snapshot()
+
+	this.So(snapshot, should.HaveLength, 1)
+	this.So(snapshot[OtherLabel].Samples, should.Equal, 20)
+	this.So(this.threshold.Threshold("random-3"), should.AlmostEqual, 0.7, 0.0001)
+	this.So(this.threshold.Threshold("login"), should.Equal, float32(0.3))
+}
+func (this *AdaptiveThresholdFixture) TestTrackedActionsBoundedWithoutAllowlist() {
+	for i := 0; i < defaultLabelLimit+10; i++ {
+		this.threshold.Record(fmt.Sprintf("action-%d", i), 0.9, true)
+	}
+
+	snapshot := this.threshold.Snapshot()
+
+	this.So(snapshot, should.HaveLength, defaultLabelLimit+1)
+	this.So(snapshot[OtherLabel].Samples, should.Equal, 10)
+}
+func (this *AdaptiveThresholdFixture) TestBaselineLowShareValidated() {
+	this.So(func() { WithBaselineLowShare(1) }, should.Panic)
+	this.So(func() { WithBaselineLowShare(-0.1) }, should.Panic)
+	this.So(func() { WithBaselineLowShare(math.NaN()) }, should.Panic)
+	this.So(func() { WithBaselineLowShare(0) }, should.NotPanic)
+}
+func (this *AdaptiveThresholdFixture) TestAdaptiveWindowValidated() {
+	this.So(func() { WithAdaptiveWindow(time.Minute, 0) }, should.Panic)
+	this.So(func() { WithAdaptiveWindow(0, 1) }, should.Panic)
+	this.So(func() { WithAdaptiveWindow(time.Nanosecond, 2) }, should.Panic)
+	this.So(func() { WithAdaptiveWindow(time.Minute, 1) }, should.NotPanic)
+}
+
+func (this *AdaptiveThresholdFixture) record(action string, count int, score float32) {
+	for i := 0; i < count; i++ {
+		this.threshold.Record(action, score, score >= 0.3)
+	}
+}
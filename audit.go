@@ -0,0 +1,71 @@
+package recaptcha
+
+import (
+	"net"
+	"time"
+)
+
+type AuditSink interface {
+	Record(AuditRecord)
+}
+
+type AuditRecord struct {
+	Timestamp time.Time `json:"timestamp"`
+	ClientIP  string    `json:"client_ip,omitempty"`
+	TokenHash string    `json:"token_hash,omitempty"`
+	Score     float32   `json:"score"`
+	Action    string    `json:"action,omitempty"`
+	Hostname  string    `json:"hostname,omitempty"`
+	Decision  Outcome   `json:"decision"`
+	Reason    string    `json:"reason,omitempty"`
+	Enforced  bool      `json:"enforced"`
+	Candidate Outcome   `json:"candidate,omitempty"`
+}
+
+func newAuditRecord(decision Decision, redactions []AuditRedaction) AuditRecord {
+	record := AuditRecord{
+		Timestamp: decision.Timestamp,
+		ClientIP:  decision.ClientIP,
+		TokenHash: decision.TokenHash,
+		Score:     decision.Result.Score,
+		Action:    decision.Result.Action,
+		Hostname:  decision.Result.Hostname,
+		Decision:  decision.Outcome,
+		Reason:    decision.Reason(),
+		Enforced:  decision.Enforced,
+		Candidate: decision.Candidate,
+	}
+
+	for _, redact := range redactions {
+		redact(&record)
+	}
+
+	return record
+}
+
+type nopAuditSink struct{}
+
+func (nopAuditSink) Record(AuditRecord) {}
+
+/* ------------------------------------------------------------------------------------------------------------------ */
+
+type AuditRedaction func(*AuditRecord)
+
+func RedactClientIP(record *AuditRecord)  { record.ClientIP = "" }
+func RedactTokenHash(record *AuditRecord) { record.TokenHash = "" }
+func RedactHostname(record *AuditRecord)  { record.Hostname = "" }
+
+func TruncateClientIP(record *AuditRecord) {
+	host := record.ClientIP
+	if value, _, err := net.SplitHostPort(host); err == nil {
+		host = value
+	}
+
+	if address := net.ParseIP(host); address == nil {
+		record.ClientIP = ""
+	} else if address.To4() != nil {
+		record.ClientIP = address.Mask(net.CIDRMask(24, 32)).String()
+	} else {
+		record.ClientIP = address.Mask(net.CIDRMask(48, 128)).String()
+	}
+}
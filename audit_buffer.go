@@ -0,0 +1,65 @@
+package recaptcha
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+type BufferedAuditSink struct {
+	inner   AuditSink
+	mutex   sync.RWMutex
+	closed  bool
+	buffer  chan AuditRecord
+	done    chan struct{}
+	dropped uint64
+}
+
+func NewBufferedAuditSink(inner AuditSink, capacity int) *BufferedAuditSink {
+	this := &BufferedAuditSink{
+		inner:  inner,
+		buffer: make(chan AuditRecord, capacity),
+		done:   make(chan struct{}),
+	}
+
+	go this.listen()
+	return this
+}
+func (this *BufferedAuditSink) listen() {
+	defer close(this.done)
+
+	for record := range this.buffer {
+		this.inner.Record(record)
+	}
+}
+
+func (this *BufferedAuditSink) Record(record AuditRecord) {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	if this.closed {
+		atomic.AddUint64(&this.dropped, 1)
+		return
+	}
+
+	select {
+	case this.buffer <- record:
+	default:
+		atomic.AddUint64(&this.dropped, 1)
+	}
+}
+
+func (this *BufferedAuditSink) Dropped() uint64 {
+	return atomic.LoadUint64(&this.dropped)
+}
+
+func (this *BufferedAuditSink) Close() error {
+	this.mutex.Lock()
+	if !this.closed {
+		this.closed = true
+		close(this.buffer)
+	}
+	this.mutex.Unlock()
+
+	<-this.done
+	return nil
+}
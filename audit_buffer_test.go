@@ -0,0 +1,66 @@
+package recaptcha
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+)
+
+func TestBufferedAuditSinkFixture(t *testing.T) {
+	gunit.Run(new(BufferedAuditSinkFixture), t)
+}
+
+type BufferedAuditSinkFixture struct {
+	*gunit.Fixture
+
+	mutex    sync.Mutex
+	release  chan struct{}
+	recorded []AuditRecord
+}
+
+func (this *BufferedAuditSinkFixture) Setup() {
+	this.release = make(chan struct{})
+}
+
+func (this *BufferedAuditSinkFixture) TestRecordsForwarded() {
+	close(this.release)
+	sink := NewBufferedAuditSink(this, 4)
+
+	sink.Record(AuditRecord{Action: "a"})
+	sink.Record(AuditRecord{Action: "b"})
+	_ = sink.Close()
+
+	this.So(this.recorded, should.Resemble, []AuditRecord{{Action: "a"}, {Action: "b"}})
+	this.So(sink.Dropped(), should.Equal, 0)
+}
+func (this *BufferedAuditSinkFixture) TestSlowSinkDoesNotBlock() {
+	sink := NewBufferedAuditSink(this, 1)
+
+	for i := 0; i < 10; i++ {
+		sink.Record(AuditRecord{})
+	}
+
+	this.So(sink.Dropped(), should.BeGreaterThanOrEqualTo, 8)
+	close(this.release)
+	_ = sink.Close()
+	this.So(uint64(len(this.recorded))+sink.Dropped(), should.Equal, 10)
+}
+func (this *BufferedAuditSinkFixture) TestRecordAfterCloseDropped() {
+	close(this.release)
+	sink := NewBufferedAuditSink(this, 1)
+	_ = sink.Close()
+
+	sink.Record(AuditRecord{})
+
+	this.So(sink.Dropped(), should.Equal, 1)
+	this.So(sink.Close(), should.BeNil)
+}
+
+func (this *BufferedAuditSinkFixture) Record(record AuditRecord) {
+	<-this.release
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.recorded = append(this.recorded, record)
+}
@@ -0,0 +1,71 @@
+package recaptcha
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+type SlogAuditSink struct {
+	logger *slog.Logger
+}
+
+func NewSlogAuditSink(logger *slog.Logger) *SlogAuditSink {
+	return &SlogAuditSink{logger: logger}
+}
+
+func (this *SlogAuditSink) Record(record AuditRecord) {
+	this.logger.LogAttrs(context.Background(), slog.LevelInfo, auditMessage,
+		slog.Time("timestamp", record.Timestamp),
+		slog.String("client_ip", record.ClientIP),
+		slog.String("token_hash", record.TokenHash),
+		slog.Float64("score", float64(record.Score)),
+		slog.String("action", record.Action),
+		slog.String("hostname", record.Hostname),
+		slog.String("decision", string(record.Decision)),
+		slog.String("reason", record.Reason),
+		slog.Bool("enforced", record.Enforced),
+		slog.String("candidate", string(record.Candidate)),
+	)
+}
+
+/* ------------------------------------------------------------------------------------------------------------------ */
+
+type JSONAuditSink struct {
+	mutex   sync.Mutex
+	writer  io.Writer
+	encoder *json.Encoder
+}
+
+func NewJSONAuditSink(writer io.Writer) *JSONAuditSink {
+	return &JSONAuditSink{writer: writer, encoder: json.NewEncoder(writer)}
+}
+func OpenJSONAuditFile(path string) (*JSONAuditSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewJSONAuditSink(file), nil
+}
+
+func (this *JSONAuditSink) Record(record AuditRecord) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	_ = this.encoder.Encode(record)
+}
+func (this *JSONAuditSink) Close() error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if closer, ok := this.writer.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+const auditMessage = "recaptcha decision"
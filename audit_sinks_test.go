@@ -0,0 +1,115 @@
+package recaptcha
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+)
+
+func TestAuditSinksFixture(t *testing.T) {
+	gunit.Run(new(AuditSinksFixture), t)
+}
+
+type AuditSinksFixture struct {
+	*gunit.Fixture
+
+	record AuditRecord
+}
+
+func (this *AuditSinksFixture) Setup() {
+	this.record = AuditRecord{
+		Timestamp: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		ClientIP:  "192.0.2.1",
+		TokenHash: "hash",
+		Score:     0.5,
+		Action:    "login",
+		Hostname:  "example.com",
+		Decision:  OutcomeAccepted,
+		Enforced:  true,
+	}
+}
+
+func (this *AuditSinksFixture) TestSlogSink() {
+	buffer := new(bytes.Buffer)
+	logger := slog.New(slog.NewJSONHandler(buffer, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) == 0 && attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return attr
+		},
+	}))
+
+	NewSlogAuditSink(logger).Record(this.record)
+
+	this.So(buffer.String(), should.EqualJSON, `{
+		"level": "INFO",
+		"msg": "recaptcha decision",
+		"timestamp": "2020-01-02T03:04:05Z",
+		"client_ip": "192.0.2.1",
+		"token_hash": "hash",
+		"score": 0.5,
+		"action": "login",
+		"hostname": "example.com",
+		"decision": "accepted",
+		"reason": "",
+		"enforced": true,
+		"candidate": ""
+	}`)
+}
+func (this *AuditSinksFixture) TestJSONLinesSink() {
+	buffer := new(bytes.Buffer)
+	sink := NewJSONAuditSink(buffer)
+
+	sink.Record(this.record)
+	sink.Record(AuditRecord{Timestamp: this.record.Timestamp, Decision: OutcomeRejected, Reason: ReasonMissingToken, Candidate: OutcomeAccepted})
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	this.So(lines, should.HaveLength, 2)
+	this.So(lines[0], should.EqualJSON, `{
+		"timestamp": "2020-01-02T03:04:05Z",
+		"client_ip": "192.0.2.1",
+		"token_hash": "hash",
+		"score": 0.5,
+		"action": "login",
+		"hostname": "example.com",
+		"decision": "accepted",
+		"enforced": true
+	}`)
+	this.So(lines[1], should.EqualJSON, `{
+		"timestamp": "2020-01-02T03:04:05Z",
+		"score": 0,
+		"decision": "rejected",
+		"reason": "missing-token",
+		"enforced": false,
+		"candidate": "accepted"
+	}`)
+	this.So(sink.Close(), should.BeNil)
+}
+func (this *AuditSinksFixture) TestJSONFileAppends() {
+	path := filepath.Join(this.T().(*testing.T).TempDir(), "audit.jsonl")
+
+	first, _ := OpenJSONAuditFile(path)
+	first.Record(this.record)
+	_ = first.Close()
+	second, err := OpenJSONAuditFile(path)
+	second.Record(this.record)
+	_ = second.Close()
+
+	contents, _ := os.ReadFile(path)
+	this.So(err, should.BeNil)
+	this.So(strings.Count(string(contents), "\n"), should.Equal, 2)
+}
+func (this *AuditSinksFixture) TestJSONFileOpenFailure() {
+	sink, err := OpenJSONAuditFile(filepath.Join(this.T().(*testing.T).TempDir(), "missing", "audit.jsonl"))
+
+	this.So(sink, should.BeNil)
+	this.So(err, should.NotBeNil)
+}
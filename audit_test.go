@@ -0,0 +1,75 @@
+package recaptcha
+
+import (
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+)
+
+func TestAuditFixture(t *testing.T) {
+	gunit.Run(new(AuditFixture), t)
+}
+
+type AuditFixture struct {
+	*gunit.Fixture
+
+	decision Decision
+}
+
+func (this *AuditFixture) Setup() {
+	this.decision = Decision{
+		Outcome:   OutcomeRejected,
+		Result:    Result{Score: 0.1, Action: "login", Hostname: "example.com", Reason: ReasonScoreBelowThreshold},
+		Timestamp: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		ClientIP:  "192.0.2.123",
+		TokenHash: hashToken("token"),
+	}
+}
+
+func (this *AuditFixture) TestRecordFromDecision() {
+	record := newAuditRecord(this.decision, nil)
+
+	this.So(record, should.Resemble, AuditRecord{
+		Timestamp: this.decision.Timestamp,
+		ClientIP:  "192.0.2.123",
+		TokenHash: "3c469e9d6c5875d37a43f353d4f88e61fcf812c66eee3457465a40b0da4153e0",
+		Score:     0.1,
+		Action:    "login",
+		Hostname:  "example.com",
+		Decision:  OutcomeRejected,
+		Reason:    ReasonScoreBelowThreshold,
+	})
+}
+func (this *AuditFixture) TestErrorCodeUsedAsReason() {
+	this.decision.Error = ErrUpstreamUnavailable
+
+	record := newAuditRecord(this.decision, nil)
+
+	this.So(record.Reason, should.Equal, "upstream-unavailable")
+}
+func (this *AuditFixture) TestRedactions() {
+	record := newAuditRecord(this.decision, []AuditRedaction{RedactClientIP, RedactTokenHash, RedactHostname})
+
+	this.So(record.ClientIP, should.BeEmpty)
+	this.So(record.TokenHash, should.BeEmpty)
+	this.So(record.Hostname, should.BeEmpty)
+	this.So(record.Action, should.Equal, "login")
+}
+func (this *AuditFixture) TestTruncateClientIP() {
+	this.So(this.truncate("192.0.2.123"), should.Equal, "192.0.2.0")
+	this.So(this.truncate("192.0.2.123:4567"), should.Equal, "192.0.2.0")
+	this.So(this.truncate("2001:db8:1234:5678::1"), should.Equal, "2001:db8:1234::")
+	this.So(this.truncate("[2001:db8:1234:5678::1]:443"), should.Equal, "2001:db8:1234::")
+	this.So(this.truncate("not-an-ip"), should.BeEmpty)
+}
+func (this *AuditFixture) TestEmptyTokenNotHashed() {
+	this.So(hashToken(""), should.BeEmpty)
+}
+
+func (this *AuditFixture) truncate(value string) string {
+	record := AuditRecord{ClientIP: value}
+	TruncateClientIP(&record)
+	return record.ClientIP
+}
@@ -0,0 +1,134 @@
+package recaptcha
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type BatchItem struct {
+	Token    string
+	ClientIP string
+}
+
+type BatchResult struct {
+	Index  int
+	Item   BatchItem
+	Result Result
+	Error  error
+}
+
+func (this *DefaultVerifier) VerifyBatch(ctx context.Context, items []BatchItem, callback func(BatchResult), options ...BatchOption) error {
+	config := newBatchConfig(options)
+	indexes := make(chan int)
+	mutex := new(sync.Mutex)
+	waiter := new(sync.WaitGroup)
+	detached := context.WithoutCancel(ctx)
+
+	for i := 0; i < config.concurrency; i++ {
+		waiter.Add(1)
+		go func() {
+			defer waiter.Done()
+			for index := range indexes {
+				if ctx.Err() != nil {
+					continue // dispatched as the batch was cancelled; the token is left unspent
+				}
+
+				// in-flight tokens are spent once submitted, so finish them and report what came back
+				result, err := this.VerifyContext(detached, items[index].Token, items[index].ClientIP)
+
+				mutex.Lock()
+				callback(BatchResult{Index: index, Item: items[index], Result: result, Error: err})
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	config.dispatch(ctx, len(items), indexes)
+	close(indexes)
+	waiter.Wait()
+	return ctx.Err()
+}
+func (this *DefaultVerifier) StreamBatch(ctx context.Context, items []BatchItem, options ...BatchOption) <-chan BatchResult {
+	results := make(chan BatchResult, len(items))
+
+	go func() {
+		defer close(results)
+		_ = this.VerifyBatch(ctx, items, func(result BatchResult) { results <- result }, options...)
+	}()
+
+	return results
+}
+
+/* ------------------------------------------------------------------------------------------------------------------ */
+
+type batchConfig struct {
+	concurrency int
+	limiter     RateLimiter
+}
+
+func newBatchConfig(options []BatchOption) *batchConfig {
+	this := &batchConfig{}
+
+	WithBatchConcurrency(defaultBatchConcurrency)(this)
+	WithBatchRateLimiter(nil)(this)
+
+	for _, option := range options {
+		option(this)
+	}
+
+	return this
+}
+
+func (this *batchConfig) dispatch(ctx context.Context, count int, indexes chan<- int) {
+	for index := 0; index < count; index++ {
+		if !this.reserve(ctx) {
+			return
+		}
+
+		select {
+		case indexes <- index:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+func (this *batchConfig) reserve(ctx context.Context) bool {
+	if this.limiter == nil {
+		return ctx.Err() == nil
+	}
+
+	for {
+		retryAfter, allowed := this.limiter.Reserve(batchRateLimitKey, acceptedCost)
+		if allowed {
+			return ctx.Err() == nil
+		}
+
+		timer := time.NewTimer(retryAfter)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		}
+	}
+}
+
+type BatchOption func(*batchConfig)
+
+func WithBatchConcurrency(value int) BatchOption {
+	return func(this *batchConfig) {
+		if value < 1 {
+			value = 1
+		}
+		this.concurrency = value
+	}
+}
+func WithBatchRateLimiter(value RateLimiter) BatchOption {
+	return func(this *batchConfig) { this.limiter = value }
+}
+
+const (
+	defaultBatchConcurrency = 8
+	batchRateLimitKey       = "batch"
+)
@@ -0,0 +1,214 @@
+package recaptcha
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/recaptcha/emulator"
+)
+
+func TestBatchFixture(t *testing.T) {
+	gunit.Run(new(BatchFixture), t)
+}
+
+type BatchFixture struct {
+	*gunit.Fixture
+
+	verifier *DefaultVerifier
+	items    []BatchItem
+
+	mutex    sync.Mutex
+	active   int
+	peak     int
+	tokens   []string
+	block    chan struct{}
+	started  chan struct{}
+	reserved int
+}
+
+func (this *BatchFixture) Setup() {
+	this.verifier = NewVerifier(WithHTTPClient(this))
+	for i := 0; i < 20; i++ {
+		this.items = append(this.items, BatchItem{Token: fmt.Sprintf("token-%02d", i), ClientIP: "ip"})
+	}
+}
+
+func (this *BatchFixture) TestEveryItemVerified() {
+	var results []BatchResult
+
+	err := this.verifier.VerifyBatch(context.Background(), this.items, func(result BatchResult) {
+		results = append(results, result)
+	})
+
+	this.So(err, should.BeNil)
+	this.So(results, should.HaveLength, len(this.items))
+	sort.Slice(results, func(i, j int) bool { return results[i].Index < results[j].Index })
+	for i, result := range results {
+		this.So(result.Item, should.Resemble, this.items[i])
+		this.So(result.Result.Accepted, should.BeTrue)
+		this.So(result.Error, should.BeNil)
+	}
+}
+func (this *BatchFixture) TestConcurrencyBounded() {
+	this.block = make(chan struct{})
+	this.started = make(chan struct{}, len(this.items))
+	go func() {
+		for i := 0; i < 3; i++ {
+			<-this.started
+		}
+		close(this.block)
+	}()
+
+	_ = this.verifier.VerifyBatch(context.Background(), this.items, func(BatchResult) {}, WithBatchConcurrency(3))
+
+	this.So(this.peak, should.Equal, 3)
+	this.So(this.tokens, should.HaveLength, len(this.items))
+}
+func (this *BatchFixture) TestRateLimiterRespected() {
+	var results int
+
+	err := this.verifier.VerifyBatch(context.Background(), this.items[:3], func(BatchResult) { results++ },
+		WithBatchRateLimiter(this))
+
+	this.So(err, should.BeNil)
+	this.So(results, should.Equal, 3)
+	this.So(this.reserved, should.Equal, 6)
+}
+func (this *BatchFixture) TestPartialResultsWhenCancelled() {
+	ctx, cancel := context.WithCancel(context.Background())
+	var results []BatchResult
+
+	err := this.verifier.VerifyBatch(ctx, this.items, func(result BatchResult) {
+		results = append(results, result)
+		if len(results) == 5 {
+			cancel()
+		}
+	}, WithBatchConcurrency(1))
+
+	this.So(err, should.Equal, context.Canceled)
+	this.So(len(results), should.BeBetweenOrEqual, 5, 6)
+	for _, result := range results {
+		this.So(result.Error, should.BeNil)
+	}
+}
+func (this *BatchFixture) TestInFlightItemsReportedWhenCancelled() {
+	ctx, cancel := context.WithCancel(context.Background())
+	this.block = make(chan struct{})
+	this.started = make(chan struct{}, len(this.items))
+	go func() {
+		for i := 0; i < 4; i++ {
+			<-this.started
+		}
+		cancel()
+		close(this.block)
+	}()
+	var results []BatchResult
+
+	err := this.verifier.VerifyBatch(ctx, this.items, func(result BatchResult) {
+		results = append(results, result)
+	}, WithBatchConcurrency(4))
+
+	this.So(err, should.Equal, context.Canceled)
+	this.So(this.tokens, should.HaveLength, 4)
+	this.So(results, should.HaveLength, 4)
+	for _, result := range results {
+		this.So(result.Error, should.BeNil)
+		this.So(result.Result.Accepted, should.BeTrue)
+	}
+}
+func (this *BatchFixture) TestStreamedInFlightItemsReportedWhenCancelled() {
+	ctx, cancel := context.WithCancel(context.Background())
+	this.block = make(chan struct{})
+	this.started = make(chan struct{}, len(this.items))
+	go func() {
+		for i := 0; i < 4; i++ {
+			<-this.started
+		}
+		cancel()
+		close(this.block)
+	}()
+	var count int
+
+	for result := range this.verifier.StreamBatch(ctx, this.items, WithBatchConcurrency(4)) {
+		this.So(result.Error, should.BeNil)
+		count++
+	}
+
+	this.So(count, should.Equal, len(this.tokens))
+	this.So(count, should.Equal, 4)
+}
+func (this *BatchFixture) TestDeadlineDoesNotDiscardSpentTokens() {
+	siteverify := emulator.New(emulator.WithSecret("secret"), emulator.WithLatency(time.Millisecond*100))
+	upstream := httptest.NewServer(siteverify)
+	defer upstream.Close()
+	verifier := NewVerifier(WithSecret(func() string { return "secret" }), WithEndpoint(upstream.URL+emulator.SiteverifyPath))
+	var items []BatchItem
+	for i := 0; i < 4; i++ {
+		items = append(items, BatchItem{Token: siteverify.Issue(emulator.Token{Score: 0.9}), ClientIP: "ip"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	var results []BatchResult
+
+	err := verifier.VerifyBatch(ctx, items, func(result BatchResult) {
+		results = append(results, result)
+	}, WithBatchConcurrency(4))
+
+	this.So(err, should.Wrap, context.DeadlineExceeded)
+	this.So(results, should.HaveLength, siteverify.Calls())
+	this.So(results, should.HaveLength, 4)
+	for _, result := range results {
+		this.So(result.Result.Accepted, should.BeTrue)
+		this.So(siteverify.Uses(result.Item.Token), should.Equal, 1)
+	}
+}
+func (this *BatchFixture) TestStreamedResults() {
+	var count int
+
+	for result := range this.verifier.StreamBatch(context.Background(), this.items) {
+		this.So(result.Result.Accepted, should.BeTrue)
+		count++
+	}
+
+	this.So(count, should.Equal, len(this.items))
+}
+
+func (this *BatchFixture) Do(request *http.Request) (*http.Response, error) {
+	this.mutex.Lock()
+	this.active++
+	if this.active > this.peak {
+		this.peak = this.active
+	}
+	this.tokens = append(this.tokens, request.FormValue("response"))
+	this.mutex.Unlock()
+
+	if this.started != nil {
+		this.started <- struct{}{}
+		<-this.block
+	}
+
+	this.mutex.Lock()
+	this.active--
+	this.mutex.Unlock()
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{contentTypeHeader: []string{jsonContentType}},
+		Body:       io.NopCloser(bytes.NewBufferString(`{"success":true,"score":0.9}`)),
+	}, nil
+}
+func (this *BatchFixture) Reserve(string, float64) (time.Duration, bool) {
+	this.reserved++
+	return time.Millisecond, this.reserved%2 == 0
+}
+func (this *BatchFixture) Charge(string, float64) {}
@@ -0,0 +1,101 @@
+package recaptcha
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type BypassClaims struct {
+	Expires time.Time
+	Action  string
+	Score   *float32
+}
+
+func BypassScore(value float32) *float32 {
+	return &value
+}
+
+func NewBypassToken(key []byte, claims BypassClaims) string {
+	requireBypassKey(key)
+
+	payload, _ := json.Marshal(bypassPayload{
+		Expires: claims.Expires.Unix(),
+		Action:  claims.Action,
+		Score:   claims.Score,
+	})
+
+	encoded := bypassTokenPrefix + bypassEncoding.EncodeToString(payload)
+	return encoded + "." + bypassEncoding.EncodeToString(signBypass(key, encoded))
+}
+
+func parseBypassToken(key []byte, token string, now time.Time) (BypassClaims, error) {
+	separator := strings.LastIndex(token, ".")
+	if separator <= len(bypassTokenPrefix) {
+		return BypassClaims{}, errMalformedBypassToken
+	}
+
+	encoded, signature := token[:separator], token[separator+1:]
+	if decoded, err := bypassEncoding.DecodeString(signature); err != nil || !hmac.Equal(decoded, signBypass(key, encoded)) {
+		return BypassClaims{}, errMalformedBypassToken
+	}
+
+	var payload bypassPayload
+	if decoded, err := bypassEncoding.DecodeString(encoded[len(bypassTokenPrefix):]); err != nil {
+		return BypassClaims{}, errMalformedBypassToken
+	} else if err = json.Unmarshal(decoded, &payload); err != nil {
+		return BypassClaims{}, errMalformedBypassToken
+	}
+
+	claims := payload.claims()
+	if !now.Before(claims.Expires) {
+		return claims, errExpiredBypassToken
+	}
+
+	return claims, nil
+}
+
+func signBypass(key []byte, value string) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
+func requireBypassKey(key []byte) {
+	if len(key) < minimumBypassKeyLength {
+		panic(errBypassKeyTooShort)
+	}
+}
+
+type bypassPayload struct {
+	Expires int64    `json:"exp"`
+	Action  string   `json:"action,omitempty"`
+	Score   *float32 `json:"score,omitempty"`
+}
+
+func (this bypassPayload) claims() BypassClaims {
+	claims := BypassClaims{Expires: time.Unix(this.Expires, 0), Action: this.Action, Score: this.Score}
+	if claims.Score == nil {
+		claims.Score = BypassScore(defaultBypassScore)
+	}
+	return claims
+}
+
+/* ------------------------------------------------------------------------------------------------------------------ */
+
+const (
+	bypassTokenPrefix      = "bypass."
+	minimumBypassKeyLength = 32
+	defaultBypassScore     = 1.0
+)
+
+var bypassEncoding = base64.RawURLEncoding
+
+var (
+	errBypassKeyTooShort    = fmt.Errorf("bypass keys must be at least %d bytes long", minimumBypassKeyLength)
+	errMalformedBypassToken = fmt.Errorf("%w: the bypass token is malformed or its signature is invalid", ErrInvalidToken)
+	errExpiredBypassToken   = fmt.Errorf("%w: the bypass token has expired", ErrInvalidToken)
+)
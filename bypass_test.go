@@ -0,0 +1,86 @@
+package recaptcha
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+)
+
+func TestBypassFixture(t *testing.T) {
+	gunit.Run(new(BypassFixture), t)
+}
+
+type BypassFixture struct {
+	*gunit.Fixture
+
+	key []byte
+	now time.Time
+}
+
+func (this *BypassFixture) Setup() {
+	this.key = []byte(strings.Repeat("k", minimumBypassKeyLength))
+	this.now = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+}
+
+func (this *BypassFixture) TestRoundTrip() {
+	token := NewBypassToken(this.key, BypassClaims{Expires: this.now.Add(time.Minute), Action: "login", Score: BypassScore(0.7)})
+
+	claims, err := parseBypassToken(this.key, token, this.now)
+
+	this.So(err, should.BeNil)
+	this.So(token, should.StartWith, bypassTokenPrefix)
+	this.So(claims, should.Resemble, BypassClaims{Expires: this.now.Add(time.Minute).Local(), Action: "login", Score: BypassScore(0.7)})
+}
+func (this *BypassFixture) TestDefaultScore() {
+	token := NewBypassToken(this.key, BypassClaims{Expires: this.now.Add(time.Minute)})
+
+	claims, _ := parseBypassToken(this.key, token, this.now)
+
+	this.So(*claims.Score, should.Equal, defaultBypassScore)
+}
+func (this *BypassFixture) TestExplicitZeroScoreKept() {
+	token := NewBypassToken(this.key, BypassClaims{Expires: this.now.Add(time.Minute), Score: BypassScore(0)})
+
+	claims, _ := parseBypassToken(this.key, token, this.now)
+
+	this.So(*claims.Score, should.Equal, float32(0))
+}
+func (this *BypassFixture) TestExpiredToken() {
+	token := NewBypassToken(this.key, BypassClaims{Expires: this.now})
+
+	_, err := parseBypassToken(this.key, token, this.now)
+
+	this.So(err, should.Equal, errExpiredBypassToken)
+	this.So(err, should.Wrap, ErrInvalidToken)
+}
+func (this *BypassFixture) TestWrongKey() {
+	token := NewBypassToken(this.key, BypassClaims{Expires: this.now.Add(time.Minute)})
+
+	_, err := parseBypassToken([]byte(strings.Repeat("x", minimumBypassKeyLength)), token, this.now)
+
+	this.So(err, should.Equal, errMalformedBypassToken)
+}
+func (this *BypassFixture) TestTamperedPayload() {
+	token := NewBypassToken(this.key, BypassClaims{Expires: this.now.Add(time.Minute), Score: BypassScore(0.1)})
+	forged := NewBypassToken(this.key, BypassClaims{Expires: this.now.Add(time.Minute), Score: BypassScore(0.9)})
+	tampered := forged[:strings.LastIndex(forged, ".")] + token[strings.LastIndex(token, "."):]
+
+	_, err := parseBypassToken(this.key, tampered, this.now)
+
+	this.So(err, should.Equal, errMalformedBypassToken)
+}
+func (this *BypassFixture) TestMalformedTokens() {
+	for _, token := range []string{"bypass.", "bypass.abc", "bypass.!!!.abc", "bypass.e30.!!!"} {
+		_, err := parseBypassToken(this.key, token, this.now)
+
+		this.So(err, should.Equal, errMalformedBypassToken)
+	}
+}
+func (this *BypassFixture) TestKeyRequired() {
+	this.So(func() { NewBypassToken(nil, BypassClaims{}) }, should.PanicWith, errBypassKeyTooShort)
+	this.So(func() { WithBypassKey(nil) }, should.PanicWith, errBypassKeyTooShort)
+	this.So(func() { WithBypassKey([]byte("short")) }, should.PanicWith, errBypassKeyTooShort)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/smartystreets/recaptcha/emulator"
+)
+
+func main() {
+	address := flag.String("listen", "127.0.0.1:8085", "The address on which to serve the emulated siteverify endpoint.")
+	secret := flag.String("secret", "", "When set, the secret that verification requests must present.")
+	latency := flag.Duration("latency", 0, "The delay added to every verification request.")
+	outage := flag.Int("outage", 0, "When set, the HTTP status code returned to every verification request.")
+	lifetime := flag.Duration("lifetime", time.Minute*2, "How long an issued token remains valid.")
+	flag.Parse()
+
+	server := emulator.New(
+		emulator.WithSecret(*secret),
+		emulator.WithLatency(*latency),
+		emulator.WithTokenLifetime(*lifetime))
+	server.SetOutage(*outage)
+
+	log.Printf("[INFO] Emulating siteverify at http://%s%s", *address, emulator.SiteverifyPath)
+	log.Printf("[INFO] Issue tokens with: curl -d score=0.9 -d action=login http://%s%s", *address, emulator.IssuePath)
+	log.Fatal(http.ListenAndServe(*address, server.Handler()))
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+type config struct {
+	Listen          string        `json:"listen"`
+	AdminListen     string        `json:"admin_listen"`
+	Upstream        string        `json:"upstream"`
+	Secret          string        `json:"secret"`
+	Endpoint        string        `json:"endpoint"`
+	Threshold       float64       `json:"threshold"`
+	Hosts           []string      `json:"hosts"`
+	Actions         []string      `json:"actions"`
+	TokenHeader     string        `json:"token_header"`
+	ClientIPHeader  string        `json:"client_ip_header"`
+	TrustedProxies  []string      `json:"trusted_proxies"`
+	ReportOnly      bool          `json:"report_only"`
+	HealthPath      string        `json:"health_path"`
+	MetricsPath     string        `json:"metrics_path"`
+	ShutdownTimeout time.Duration `json:"shutdown_timeout"`
+
+	ReadHeaderTimeout time.Duration `json:"read_header_timeout"`
+	ReadTimeout       time.Duration `json:"read_timeout"`
+	WriteTimeout      time.Duration `json:"write_timeout"`
+	IdleTimeout       time.Duration `json:"idle_timeout"`
+}
+
+func parseConfig(arguments []string) (config, error) {
+	this := config{
+		Listen:          "127.0.0.1:8080",
+		AdminListen:     "127.0.0.1:9090",
+		Endpoint:        "https://www.google.com/recaptcha/api/siteverify",
+		Threshold:       0.3,
+		HealthPath:      "/healthz",
+		MetricsPath:     "/metrics",
+		ShutdownTimeout: time.Second * 10,
+
+		ReadHeaderTimeout: time.Second * 5,
+		ReadTimeout:       time.Second * 30,
+		WriteTimeout:      time.Second * 60,
+		IdleTimeout:       time.Second * 120,
+	}
+
+	flags := flag.NewFlagSet("recaptcha-proxy", flag.ExitOnError)
+	path := flags.String("config", "", "A JSON file holding the configuration; flags given explicitly take precedence.")
+	listen := flags.String("listen", this.Listen, "The address on which to accept requests.")
+	adminListen := flags.String("admin-listen", this.AdminListen, "The address serving health checks and metrics, kept off the public listener.")
+	upstream := flags.String("upstream", "", "The URL of the upstream service requests are proxied to.")
+	secret := flags.String("secret", "", "The site secret (defaults to $RECAPTCHA_SECRET).")
+	endpoint := flags.String("endpoint", this.Endpoint, "The siteverify endpoint.")
+	threshold := flags.Float64("threshold", this.Threshold, "The minimum score required.")
+	hosts := flags.String("hosts", "", "Comma-separated hostnames allowed (any when empty).")
+	actions := flags.String("actions", "", "Comma-separated actions allowed (any when empty).")
+	tokenHeader := flags.String("token-header", "", "When set, the request header holding the token; otherwise the query string is used.")
+	clientIPHeader := flags.String("client-ip-header", "", "When set, the request header holding the client IP, honored only from trusted proxies; otherwise the peer address is used.")
+	trustedProxies := flags.String("trusted-proxies", "", "Comma-separated proxy addresses or CIDR ranges allowed to supply the client IP header.")
+	reportOnly := flags.Bool("report-only", false, "Record decisions without rejecting requests.")
+	healthPath := flags.String("health-path", this.HealthPath, "The path answering health checks.")
+	metricsPath := flags.String("metrics-path", this.MetricsPath, "The path serving Prometheus metrics.")
+	shutdownTimeout := flags.Duration("shutdown-timeout", this.ShutdownTimeout, "How long to wait for in-flight requests on shutdown.")
+	readHeaderTimeout := flags.Duration("read-header-timeout", this.ReadHeaderTimeout, "How long to wait for request headers.")
+	readTimeout := flags.Duration("read-timeout", this.ReadTimeout, "How long to wait for an entire request, including the body.")
+	writeTimeout := flags.Duration("write-timeout", this.WriteTimeout, "How long to spend writing a response, including the upstream call.")
+	idleTimeout := flags.Duration("idle-timeout", this.IdleTimeout, "How long to keep idle connections open.")
+	_ = flags.Parse(arguments)
+
+	if len(*path) > 0 {
+		if err := this.load(*path); err != nil {
+			return this, err
+		}
+	}
+
+	flags.Visit(func(item *flag.Flag) {
+		switch item.Name {
+		case "listen":
+			this.Listen = *listen
+		case "admin-listen":
+			this.AdminListen = *adminListen
+		case "upstream":
+			this.Upstream = *upstream
+		case "secret":
+			this.Secret = *secret
+		case "endpoint":
+			this.Endpoint = *endpoint
+		case "threshold":
+			this.Threshold = *threshold
+		case "hosts":
+			this.Hosts = splitList(*hosts)
+		case "actions":
+			this.Actions = splitList(*actions)
+		case "token-header":
+			this.TokenHeader = *tokenHeader
+		case "client-ip-header":
+			this.ClientIPHeader = *clientIPHeader
+		case "trusted-proxies":
+			this.TrustedProxies = splitList(*trustedProxies)
+		case "report-only":
+			this.ReportOnly = *reportOnly
+		case "health-path":
+			this.HealthPath = *healthPath
+		case "metrics-path":
+			this.MetricsPath = *metricsPath
+		case "shutdown-timeout":
+			this.ShutdownTimeout = *shutdownTimeout
+		case "read-header-timeout":
+			this.ReadHeaderTimeout = *readHeaderTimeout
+		case "read-timeout":
+			this.ReadTimeout = *readTimeout
+		case "write-timeout":
+			this.WriteTimeout = *writeTimeout
+		case "idle-timeout":
+			this.IdleTimeout = *idleTimeout
+		}
+	})
+
+	if len(this.Secret) == 0 {
+		this.Secret = os.Getenv("RECAPTCHA_SECRET")
+	}
+	return this, this.validate()
+}
+func (this config) validate() error {
+	if len(this.ClientIPHeader) > 0 && len(this.TrustedProxies) == 0 {
+		return errors.New("a client IP header requires trusted proxies")
+	}
+
+	for _, value := range this.TrustedProxies {
+		if _, _, err := net.ParseCIDR(value); err != nil && net.ParseIP(value) == nil {
+			return fmt.Errorf("%q is not an IP address or CIDR range", value)
+		}
+	}
+
+	return nil
+}
+func (this *config) load(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+
+	var raw struct {
+		*config
+		ShutdownTimeout   string `json:"shutdown_timeout"`
+		ReadHeaderTimeout string `json:"read_header_timeout"`
+		ReadTimeout       string `json:"read_timeout"`
+		WriteTimeout      string `json:"write_timeout"`
+		IdleTimeout       string `json:"idle_timeout"`
+	}
+	raw.config = this
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&raw); err != nil {
+		return err
+	}
+
+	durations := []struct {
+		value  string
+		target *time.Duration
+	}{
+		{value: raw.ShutdownTimeout, target: &this.ShutdownTimeout},
+		{value: raw.ReadHeaderTimeout, target: &this.ReadHeaderTimeout},
+		{value: raw.ReadTimeout, target: &this.ReadTimeout},
+		{value: raw.WriteTimeout, target: &this.WriteTimeout},
+		{value: raw.IdleTimeout, target: &this.IdleTimeout},
+	}
+	for _, duration := range durations {
+		if len(duration.value) == 0 {
+			continue
+		}
+		if *duration.target, err = time.ParseDuration(duration.value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func splitList(value string) (values []string) {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			values = append(values, item)
+		}
+	}
+
+	return values
+}
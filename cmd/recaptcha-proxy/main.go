@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/smartystreets/recaptcha"
+)
+
+func main() {
+	config, err := parseConfig(os.Args[1:])
+	if err != nil {
+		log.Fatalf("[ERROR] Unable to load configuration: %s", err)
+	}
+
+	upstream, err := url.Parse(config.Upstream)
+	if err != nil || len(upstream.Host) == 0 {
+		log.Fatalf("[ERROR] An absolute upstream URL is required, got %q.", config.Upstream)
+	}
+
+	metrics := recaptcha.NewPrometheusObserver(
+		recaptcha.WithObservedActions(config.Actions...),
+		recaptcha.WithObservedHostnames(config.Hosts...))
+	verifier := recaptcha.NewVerifier(
+		recaptcha.WithSecret(func() string { return config.Secret }),
+		recaptcha.WithEndpoint(config.Endpoint),
+		recaptcha.WithRequiredThreshold(float32(config.Threshold)),
+		recaptcha.WithAllowedHosts(config.Hosts...),
+		recaptcha.WithAllowedActions(config.Actions...))
+	handler := recaptcha.NewHandler(verifier,
+		recaptcha.WithInnerHandler(httputil.NewSingleHostReverseProxy(upstream)),
+		recaptcha.WithTokenReader(tokenReader(config.TokenHeader)),
+		recaptcha.WithClientIPReader(clientIPReader(config.ClientIPHeader, config.TrustedProxies)),
+		recaptcha.WithReportOnly(config.ReportOnly),
+		recaptcha.WithObserver(metrics))
+
+	admin := http.NewServeMux()
+	admin.HandleFunc(config.HealthPath, func(response http.ResponseWriter, _ *http.Request) {
+		_, _ = response.Write([]byte("OK"))
+	})
+	admin.Handle(config.MetricsPath, metrics)
+
+	public := newServer(config.Listen, handler, config)
+	private := newServer(config.AdminListen, admin, config)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+		log.Printf("[INFO] Shutting down, waiting up to %s for in-flight requests.", config.ShutdownTimeout)
+		shutdown, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+		defer cancel()
+		for _, server := range []*http.Server{public, private} {
+			if err := server.Shutdown(shutdown); err != nil {
+				log.Printf("[WARN] Unable to shut down %s gracefully: %s", server.Addr, err)
+			}
+		}
+	}()
+
+	log.Printf("[INFO] Serving health checks and metrics on http://%s", config.AdminListen)
+	go serve(private)
+
+	log.Printf("[INFO] Proxying http://%s to %s", config.Listen, upstream)
+	serve(public)
+	<-stopped
+}
+func newServer(address string, handler http.Handler, config config) *http.Server {
+	return &http.Server{
+		Addr:              address,
+		Handler:           handler,
+		ReadHeaderTimeout: config.ReadHeaderTimeout,
+		ReadTimeout:       config.ReadTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		IdleTimeout:       config.IdleTimeout,
+	}
+}
+func serve(server *http.Server) {
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatalf("[ERROR] %s", err)
+	}
+}
+
+func tokenReader(header string) func(*http.Request) string {
+	query := func(request *http.Request) string { return request.URL.Query().Get(recaptcha.DefaultFormTokenName) }
+	if len(header) == 0 {
+		return query
+	}
+
+	fromHeader := recaptcha.HeaderTokenReader(header)
+	return func(request *http.Request) string {
+		if token := fromHeader(request); len(token) > 0 {
+			return token
+		}
+		return query(request)
+	}
+}
+func clientIPReader(header string, trustedProxies []string) func(*http.Request) string {
+	if len(header) == 0 {
+		return func(request *http.Request) string {
+			if host, _, err := net.SplitHostPort(request.RemoteAddr); err == nil {
+				return host
+			}
+			return request.RemoteAddr
+		}
+	}
+
+	return recaptcha.TrustedProxyClientIPReader(header, trustedProxies...)
+}
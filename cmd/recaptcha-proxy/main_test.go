@@ -0,0 +1,204 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/recaptcha"
+)
+
+func TestProxyFixture(t *testing.T) {
+	gunit.Run(new(ProxyFixture), t)
+}
+
+type ProxyFixture struct {
+	*gunit.Fixture
+
+	path    string
+	request *http.Request
+}
+
+func (this *ProxyFixture) Setup() {
+	this.request = httptest.NewRequest(http.MethodGet, "/?"+recaptcha.DefaultFormTokenName+"=query-token", nil)
+	this.request.RemoteAddr = "10.0.0.1:1234"
+}
+func (this *ProxyFixture) Teardown() {
+	if len(this.path) > 0 {
+		_ = os.Remove(this.path)
+	}
+}
+
+func (this *ProxyFixture) TestDefaults() {
+	config, err := parseConfig(nil)
+
+	this.So(err, should.BeNil)
+	this.So(config.Listen, should.Equal, "127.0.0.1:8080")
+	this.So(config.AdminListen, should.Equal, "127.0.0.1:9090")
+	this.So(config.Threshold, should.Equal, 0.3)
+	this.So(config.HealthPath, should.Equal, "/healthz")
+	this.So(config.MetricsPath, should.Equal, "/metrics")
+	this.So(config.ShutdownTimeout, should.Equal, time.Second*10)
+	this.So(config.ReadHeaderTimeout, should.Equal, time.Second*5)
+	this.So(config.ReadTimeout, should.Equal, time.Second*30)
+	this.So(config.WriteTimeout, should.Equal, time.Second*60)
+	this.So(config.IdleTimeout, should.Equal, time.Second*120)
+}
+func (this *ProxyFixture) TestFlags() {
+	config, err := parseConfig([]string{
+		"-listen", ":80",
+		"-admin-listen", "127.0.0.1:9999",
+		"-upstream", "http://backend",
+		"-threshold", "0.5",
+		"-hosts", "example.com, *.example.com,",
+		"-actions", "login",
+		"-report-only",
+		"-shutdown-timeout", "3s",
+		"-client-ip-header", "X-Client-IP",
+		"-trusted-proxies", "10.0.0.0/8, 192.0.2.1",
+		"-read-header-timeout", "1s",
+		"-read-timeout", "2s",
+		"-write-timeout", "4s",
+		"-idle-timeout", "5s",
+	})
+
+	this.So(err, should.BeNil)
+	this.So(config.Listen, should.Equal, ":80")
+	this.So(config.AdminListen, should.Equal, "127.0.0.1:9999")
+	this.So(config.Upstream, should.Equal, "http://backend")
+	this.So(config.Threshold, should.Equal, 0.5)
+	this.So(config.Hosts, should.Resemble, []string{"example.com", "*.example.com"})
+	this.So(config.Actions, should.Resemble, []string{"login"})
+	this.So(config.ReportOnly, should.BeTrue)
+	this.So(config.ShutdownTimeout, should.Equal, time.Second*3)
+	this.So(config.ClientIPHeader, should.Equal, "X-Client-IP")
+	this.So(config.TrustedProxies, should.Resemble, []string{"10.0.0.0/8", "192.0.2.1"})
+	this.So(config.ReadHeaderTimeout, should.Equal, time.Second)
+	this.So(config.ReadTimeout, should.Equal, time.Second*2)
+	this.So(config.WriteTimeout, should.Equal, time.Second*4)
+	this.So(config.IdleTimeout, should.Equal, time.Second*5)
+}
+func (this *ProxyFixture) TestConfigFile() {
+	this.writeConfig(`{"listen": ":81", "admin_listen": ":9091", "upstream": "http://backend", "hosts": ["example.com"], "shutdown_timeout": "5s", "read_header_timeout": "2s", "trusted_proxies": ["10.0.0.1"]}`)
+
+	config, err := parseConfig([]string{"-config", this.path})
+
+	this.So(err, should.BeNil)
+	this.So(config.Listen, should.Equal, ":81")
+	this.So(config.AdminListen, should.Equal, ":9091")
+	this.So(config.Upstream, should.Equal, "http://backend")
+	this.So(config.Hosts, should.Resemble, []string{"example.com"})
+	this.So(config.ShutdownTimeout, should.Equal, time.Second*5)
+	this.So(config.ReadHeaderTimeout, should.Equal, time.Second*2)
+	this.So(config.TrustedProxies, should.Resemble, []string{"10.0.0.1"})
+	this.So(config.Threshold, should.Equal, 0.3)
+}
+func (this *ProxyFixture) TestExplicitFlagsTakePrecedenceOverConfigFile() {
+	this.writeConfig(`{"listen": ":81", "upstream": "http://backend"}`)
+
+	config, err := parseConfig([]string{"-listen", ":82", "-config", this.path})
+
+	this.So(err, should.BeNil)
+	this.So(config.Listen, should.Equal, ":82")
+	this.So(config.Upstream, should.Equal, "http://backend")
+}
+func (this *ProxyFixture) TestUnknownConfigFieldsRejected() {
+	this.writeConfig(`{"listen": ":81", "unknown": true}`)
+
+	_, err := parseConfig([]string{"-config", this.path})
+
+	this.So(err, should.NotBeNil)
+}
+func (this *ProxyFixture) TestMissingConfigFileRejected() {
+	_, err := parseConfig([]string{"-config", "/does/not/exist.json"})
+
+	this.So(err, should.NotBeNil)
+}
+func (this *ProxyFixture) TestBadDurationInConfigFileRejected() {
+	this.writeConfig(`{"idle_timeout": "forever"}`)
+
+	_, err := parseConfig([]string{"-config", this.path})
+
+	this.So(err, should.NotBeNil)
+}
+func (this *ProxyFixture) TestClientIPHeaderRequiresTrustedProxies() {
+	_, err := parseConfig([]string{"-client-ip-header", "X-Client-IP"})
+
+	this.So(err, should.NotBeNil)
+}
+func (this *ProxyFixture) TestBadTrustedProxyRejected() {
+	_, err := parseConfig([]string{"-client-ip-header", "X-Client-IP", "-trusted-proxies", "not-a-network"})
+
+	this.So(err, should.NotBeNil)
+}
+func (this *ProxyFixture) TestSecretReadFromEnvironmentAfterParsing() {
+	_ = os.Setenv("RECAPTCHA_SECRET", "environment-secret")
+	defer func() { _ = os.Unsetenv("RECAPTCHA_SECRET") }()
+
+	fromEnvironment, _ := parseConfig(nil)
+	explicit, _ := parseConfig([]string{"-secret", "flag-secret"})
+
+	this.So(fromEnvironment.Secret, should.Equal, "environment-secret")
+	this.So(explicit.Secret, should.Equal, "flag-secret")
+}
+
+func (this *ProxyFixture) TestTokenReadFromQueryByDefault() {
+	this.So(tokenReader("")(this.request), should.Equal, "query-token")
+}
+func (this *ProxyFixture) TestTokenReadFromHeaderWhenConfigured() {
+	this.request.Header.Set("X-Token", "header-token")
+
+	this.So(tokenReader("X-Token")(this.request), should.Equal, "header-token")
+}
+func (this *ProxyFixture) TestTokenFallsBackToQueryWithoutHeader() {
+	this.So(tokenReader("X-Token")(this.request), should.Equal, "query-token")
+}
+
+func (this *ProxyFixture) TestClientIPFromPeerByDefault() {
+	this.request.Header.Set("X-Client-IP", "1.2.3.4")
+
+	this.So(clientIPReader("", nil)(this.request), should.Equal, "10.0.0.1")
+}
+func (this *ProxyFixture) TestClientIPFromHeaderWhenPeerTrusted() {
+	this.request.Header.Set("X-Client-IP", "1.2.3.4, 5.6.7.8")
+
+	this.So(clientIPReader("X-Client-IP", []string{"10.0.0.0/8"})(this.request), should.Equal, "5.6.7.8")
+}
+func (this *ProxyFixture) TestClientIPHeaderIgnoredFromUntrustedPeer() {
+	this.request.Header.Set("X-Client-IP", "1.2.3.4")
+
+	this.So(clientIPReader("X-Client-IP", []string{"192.0.2.1"})(this.request), should.Equal, "10.0.0.1")
+}
+func (this *ProxyFixture) TestClientIPFallsBackToPeerWithoutHeader() {
+	this.So(clientIPReader("X-Client-IP", []string{"10.0.0.0/8"})(this.request), should.Equal, "10.0.0.1")
+}
+func (this *ProxyFixture) TestClientIPKeptWhenPeerHasNoPort() {
+	this.request.RemoteAddr = "10.0.0.2"
+
+	this.So(clientIPReader("", nil)(this.request), should.Equal, "10.0.0.2")
+}
+
+func (this *ProxyFixture) TestServerTimeoutsConfigured() {
+	config, _ := parseConfig([]string{"-read-header-timeout", "1s"})
+
+	server := newServer(":80", http.NotFoundHandler(), config)
+
+	this.So(server.ReadHeaderTimeout, should.Equal, time.Second)
+	this.So(server.ReadTimeout, should.Equal, config.ReadTimeout)
+	this.So(server.WriteTimeout, should.Equal, config.WriteTimeout)
+	this.So(server.IdleTimeout, should.Equal, config.IdleTimeout)
+}
+
+func (this *ProxyFixture) writeConfig(contents string) {
+	file, err := os.CreateTemp("", "recaptcha-proxy-*.json")
+	this.So(err, should.BeNil)
+	defer func() { _ = file.Close() }()
+
+	this.path = file.Name()
+	_, err = file.WriteString(contents)
+	this.So(err, should.BeNil)
+}
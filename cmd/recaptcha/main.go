@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/smartystreets/recaptcha"
+)
+
+func main() {
+	arguments, err := parseArguments(os.Args[1:], os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(exitAccepted)
+	} else if err != nil {
+		os.Exit(exitUsage)
+	}
+
+	if arguments.Token == "-" {
+		raw, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			exit(exitUsage, "unable to read the token from stdin: %s", err)
+		}
+		arguments.Token = strings.TrimSpace(string(raw))
+	}
+	if len(arguments.Token) == 0 {
+		exit(exitUsage, "a token is required")
+	}
+	if arguments.Format != formatTable && arguments.Format != formatJSON {
+		exit(exitUsage, "unknown format %q", arguments.Format)
+	}
+
+	config := checkConfig{Threshold: float32(arguments.Threshold), Hosts: splitList(arguments.Hosts), Actions: splitList(arguments.Actions)}
+	verifier := recaptcha.NewVerifier(
+		recaptcha.WithSecret(func() string { return arguments.Secret }),
+		recaptcha.WithEndpoint(arguments.Endpoint),
+		recaptcha.WithRequiredThreshold(config.Threshold),
+		recaptcha.WithAllowedHosts(config.Hosts...),
+		recaptcha.WithAllowedActions(config.Actions...))
+
+	ctx, cancel := context.WithTimeout(context.Background(), arguments.Timeout)
+	defer cancel()
+
+	result, err := verifier.VerifyContext(ctx, arguments.Token, arguments.RemoteIP)
+	report := newReport(result, err, config)
+
+	if arguments.Format == formatJSON {
+		err = report.writeJSON(os.Stdout)
+	} else {
+		err = report.writeTable(os.Stdout)
+	}
+	if err != nil {
+		exit(exitUsage, "unable to write the report: %s", err)
+	}
+
+	os.Exit(exitCode(result, report.err))
+}
+
+type arguments struct {
+	Token     string
+	Secret    string
+	Endpoint  string
+	RemoteIP  string
+	Threshold float64
+	Hosts     string
+	Actions   string
+	Format    string
+	Timeout   time.Duration
+}
+
+func parseArguments(args []string, output io.Writer) (arguments, error) {
+	var parsed arguments
+	flags := flag.NewFlagSet("recaptcha", flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.StringVar(&parsed.Token, "token", "", "The token to verify, or '-' to read it from stdin.")
+	flags.StringVar(&parsed.Secret, "secret", "", "The site secret (defaults to $RECAPTCHA_SECRET).")
+	flags.StringVar(&parsed.Endpoint, "endpoint", "https://www.google.com/recaptcha/api/siteverify", "The siteverify endpoint.")
+	flags.StringVar(&parsed.RemoteIP, "remote-ip", "", "The client IP address sent along with the token.")
+	flags.Float64Var(&parsed.Threshold, "threshold", 0.3, "The minimum score required.")
+	flags.StringVar(&parsed.Hosts, "hosts", "", "Comma-separated hostnames allowed (any when empty).")
+	flags.StringVar(&parsed.Actions, "actions", "", "Comma-separated actions allowed (any when empty).")
+	flags.StringVar(&parsed.Format, "format", formatTable, "The output format: table or json.")
+	flags.DurationVar(&parsed.Timeout, "timeout", time.Second*10, "How long to wait for the verification endpoint.")
+	if err := flags.Parse(args); err != nil {
+		return parsed, err
+	}
+
+	if len(parsed.Secret) == 0 {
+		parsed.Secret = os.Getenv("RECAPTCHA_SECRET")
+	}
+	return parsed, nil
+}
+
+func exitCode(result recaptcha.Result, err error) int {
+	switch {
+	case errors.Is(err, recaptcha.ErrLookupFailure), errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return exitUnavailable
+	case err != nil && !errors.Is(err, recaptcha.ErrInvalidToken):
+		return exitConfig
+	case result.Accepted && err == nil:
+		return exitAccepted
+	default:
+		return exitRejected
+	}
+}
+func exit(code int, format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "recaptcha: "+format+"\n", args...)
+	os.Exit(code)
+}
+func splitList(value string) (values []string) {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			values = append(values, item)
+		}
+	}
+
+	return values
+}
+
+const (
+	exitAccepted    = 0
+	exitRejected    = 1
+	exitUnavailable = 2
+	exitConfig      = 3
+	exitUsage       = 64
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
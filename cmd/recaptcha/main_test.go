@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/recaptcha"
+)
+
+func TestCheckFixture(t *testing.T) {
+	gunit.Run(new(CheckFixture), t)
+}
+
+type CheckFixture struct {
+	*gunit.Fixture
+
+	config checkConfig
+}
+
+func (this *CheckFixture) Setup() {
+	this.config = checkConfig{Threshold: 0.5, Hosts: []string{"example.com"}, Actions: []string{"login"}}
+}
+
+func (this *CheckFixture) TestExitCodes() {
+	rejected := &recaptcha.VerificationError{Codes: []string{recaptcha.ErrorCodeDuplicate}}
+	misconfigured := &recaptcha.VerificationError{Codes: []string{"invalid-input-secret"}}
+
+	this.So(exitCode(recaptcha.Result{Accepted: true}, nil), should.Equal, exitAccepted)
+	this.So(exitCode(recaptcha.Result{}, nil), should.Equal, exitRejected)
+	this.So(exitCode(recaptcha.Result{}, rejected), should.Equal, exitRejected)
+	this.So(exitCode(recaptcha.Result{Accepted: true}, recaptcha.ErrUpstreamUnavailable), should.Equal, exitUnavailable)
+	this.So(exitCode(recaptcha.Result{}, misconfigured), should.Equal, exitConfig)
+	this.So(exitCode(recaptcha.Result{}, recaptcha.ErrUpstreamRejected), should.Equal, exitConfig)
+	this.So(exitCode(recaptcha.Result{}, context.DeadlineExceeded), should.Equal, exitUnavailable)
+	this.So(exitCode(recaptcha.Result{}, context.Canceled), should.Equal, exitUnavailable)
+}
+
+func (this *CheckFixture) TestArguments() {
+	arguments, err := parseArguments([]string{"-token", "abc", "-threshold", "0.7", "-timeout", "3s"}, new(bytes.Buffer))
+
+	this.So(err, should.BeNil)
+	this.So(arguments.Token, should.Equal, "abc")
+	this.So(arguments.Threshold, should.Equal, 0.7)
+	this.So(arguments.Timeout, should.Equal, time.Second*3)
+	this.So(arguments.Format, should.Equal, formatTable)
+}
+func (this *CheckFixture) TestUnknownArgumentRejected() {
+	output := new(bytes.Buffer)
+
+	_, err := parseArguments([]string{"-unknown"}, output)
+
+	this.So(err, should.NotBeNil)
+	this.So(output.String(), should.ContainSubstring, "-unknown")
+}
+func (this *CheckFixture) TestSecretReadFromEnvironmentAfterParsing() {
+	_ = os.Setenv("RECAPTCHA_SECRET", "environment-secret")
+	defer func() { _ = os.Unsetenv("RECAPTCHA_SECRET") }()
+	output := new(bytes.Buffer)
+
+	fromEnvironment, _ := parseArguments(nil, output)
+	explicit, _ := parseArguments([]string{"-secret", "flag-secret"}, output)
+	_, err := parseArguments([]string{"-h"}, output)
+
+	this.So(fromEnvironment.Secret, should.Equal, "environment-secret")
+	this.So(explicit.Secret, should.Equal, "flag-secret")
+	this.So(err, should.Equal, flag.ErrHelp)
+	this.So(output.String(), should.NotContainSubstring, "environment-secret")
+}
+
+func (this *CheckFixture) TestSplitList() {
+	this.So(splitList(""), should.BeEmpty)
+	this.So(splitList(" , ,"), should.BeEmpty)
+	this.So(splitList("a, b ,,c"), should.Resemble, []string{"a", "b", "c"})
+}
+
+func (this *CheckFixture) TestAcceptedReportPassesEveryCheck() {
+	result := recaptcha.Result{Accepted: true, Score: 0.75, Action: "login", Hostname: "example.com"}
+
+	report := newReport(result, nil, this.config)
+
+	this.So(report.Accepted, should.BeTrue)
+	this.So(report.Error, should.BeEmpty)
+	for _, item := range report.Checks {
+		this.So(item.Passed, should.BeTrue)
+	}
+	this.So(report.Checks[2], should.Resemble, check{Name: "score", Passed: true, Expected: ">= 0.5", Actual: "0.75"})
+}
+func (this *CheckFixture) TestFailedChecksReported() {
+	result := recaptcha.Result{Score: 0.25, Action: "signup", Hostname: "attacker.net"}
+
+	report := newReport(result, nil, this.config)
+
+	this.So(report.Accepted, should.BeFalse)
+	this.So(this.passed(report), should.Resemble, map[string]bool{
+		"lookup": true, "success": true, "score": false, "hostname": false, "action": false,
+	})
+}
+func (this *CheckFixture) TestLookupFailureFailsEveryCheck() {
+	report := newReport(recaptcha.Result{}, recaptcha.ErrUpstreamUnavailable, this.config)
+
+	this.So(report.Error, should.Equal, recaptcha.ErrUpstreamUnavailable.Error())
+	this.So(report.Checks[0].Actual, should.Equal, recaptcha.ErrUpstreamUnavailable.Error())
+	this.So(this.passed(report), should.Resemble, map[string]bool{
+		"lookup": false, "success": false, "score": false, "hostname": false, "action": false,
+	})
+}
+func (this *CheckFixture) TestErrorCodesFailSuccessCheck() {
+	result := recaptcha.Result{ErrorCodes: []string{recaptcha.ErrorCodeDuplicate}}
+	err := &recaptcha.VerificationError{Codes: result.ErrorCodes}
+
+	report := newReport(result, err, this.config)
+
+	this.So(report.Checks[0].Passed, should.BeTrue)
+	this.So(report.Checks[1], should.Resemble, check{Name: "success", Expected: "no error codes", Actual: recaptcha.ErrorCodeDuplicate})
+}
+
+func (this *CheckFixture) TestNativeAppIdentifiersReported() {
+	result := recaptcha.Result{Accepted: true, Score: 0.9, AndroidPackageName: "com.example.app", IOSBundleID: "com.example.ios"}
+	report := newReport(result, nil, this.config)
+	table, encoded := new(bytes.Buffer), new(bytes.Buffer)
+
+	this.So(report.writeTable(table), should.BeNil)
+	this.So(report.writeJSON(encoded), should.BeNil)
+
+	var decoded map[string]interface{}
+	_ = json.Unmarshal(encoded.Bytes(), &decoded)
+	this.So(table.String(), should.ContainSubstring, "android_package_name  com.example.app\n")
+	this.So(table.String(), should.ContainSubstring, "ios_bundle_id         com.example.ios\n")
+	this.So(table.String(), should.ContainSubstring, "score                 0.9\n")
+	this.So(decoded["android_package_name"], should.Equal, "com.example.app")
+	this.So(decoded["ios_bundle_id"], should.Equal, "com.example.ios")
+}
+
+func (this *CheckFixture) passed(report report) map[string]bool {
+	passed := make(map[string]bool)
+	for _, item := range report.Checks {
+		passed[item.Name] = item.Passed
+	}
+	return passed
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/smartystreets/recaptcha"
+)
+
+type checkConfig struct {
+	Threshold float32
+	Hosts     []string
+	Actions   []string
+}
+
+type report struct {
+	Accepted           bool      `json:"accepted"`
+	Score              float32   `json:"score"`
+	Action             string    `json:"action"`
+	Hostname           string    `json:"hostname"`
+	AndroidPackageName string    `json:"android_package_name,omitempty"`
+	IOSBundleID        string    `json:"ios_bundle_id,omitempty"`
+	ChallengeTS        time.Time `json:"challenge_ts"`
+	ErrorCodes         []string  `json:"error_codes"`
+	Reason             string    `json:"reason,omitempty"`
+	Error              string    `json:"error,omitempty"`
+	Checks             []check   `json:"checks"`
+
+	err error
+}
+
+type check struct {
+	Name     string `json:"name"`
+	Passed   bool   `json:"passed"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+func newReport(result recaptcha.Result, err error, config checkConfig) report {
+	this := report{
+		Accepted:           result.Accepted && err == nil,
+		Score:              result.Score,
+		Action:             result.Action,
+		Hostname:           result.Hostname,
+		AndroidPackageName: result.AndroidPackageName,
+		IOSBundleID:        result.IOSBundleID,
+		ChallengeTS:        result.ChallengeTS,
+		ErrorCodes:         result.ErrorCodes,
+		Reason:             result.Reason,
+		err:                err,
+	}
+	if err != nil {
+		this.Error = err.Error()
+	}
+
+	lookedUp := err == nil || len(result.ErrorCodes) > 0
+	this.Checks = []check{
+		{Name: "lookup", Passed: lookedUp, Expected: "response received", Actual: describeLookup(lookedUp, err)},
+		{Name: "success", Passed: lookedUp && len(result.ErrorCodes) == 0, Expected: "no error codes", Actual: orNone(strings.Join(result.ErrorCodes, ","))},
+		{Name: "score", Passed: lookedUp && result.Score >= config.Threshold, Expected: fmt.Sprintf(">= %g", config.Threshold), Actual: fmt.Sprintf("%g", result.Score)},
+		{Name: "hostname", Passed: lookedUp && allows(recaptcha.WithAllowedHosts(config.Hosts...), recaptcha.Result{Hostname: result.Hostname}), Expected: orAny(config.Hosts), Actual: orNone(result.Hostname)},
+		{Name: "action", Passed: lookedUp && allows(recaptcha.WithAllowedActions(config.Actions...), recaptcha.Result{Action: result.Action}), Expected: orAny(config.Actions), Actual: orNone(result.Action)},
+	}
+
+	return this
+}
+
+func (this report) writeJSON(writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(this)
+}
+func (this report) writeTable(writer io.Writer) error {
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(table, "FIELD\tVALUE\n")
+	fmt.Fprintf(table, "accepted\t%t\n", this.Accepted)
+	fmt.Fprintf(table, "score\t%g\n", this.Score)
+	fmt.Fprintf(table, "action\t%s\n", orNone(this.Action))
+	fmt.Fprintf(table, "hostname\t%s\n", orNone(this.Hostname))
+	fmt.Fprintf(table, "android_package_name\t%s\n", orNone(this.AndroidPackageName))
+	fmt.Fprintf(table, "ios_bundle_id\t%s\n", orNone(this.IOSBundleID))
+	fmt.Fprintf(table, "challenge_ts\t%s\n", formatTimestamp(this.ChallengeTS))
+	fmt.Fprintf(table, "error_codes\t%s\n", orNone(strings.Join(this.ErrorCodes, ",")))
+	fmt.Fprintf(table, "reason\t%s\n", orNone(this.Reason))
+	fmt.Fprintf(table, "error\t%s\n", orNone(this.Error))
+	fmt.Fprintf(table, "\t\n")
+	fmt.Fprintf(table, "CHECK\tRESULT\tEXPECTED\tACTUAL\n")
+	for _, item := range this.Checks {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", item.Name, passOrFail(item.Passed), item.Expected, item.Actual)
+	}
+
+	return table.Flush()
+}
+
+func describeLookup(lookedUp bool, err error) string {
+	if lookedUp {
+		return "response received"
+	}
+	return err.Error()
+}
+func allows(option recaptcha.VerifierOption, result recaptcha.Result) bool {
+	return recaptcha.NewPolicy(option, recaptcha.WithRequiredThreshold(0)).Accepts(result)
+}
+func passOrFail(passed bool) string {
+	if passed {
+		return "PASS"
+	}
+	return "FAIL"
+}
+func orAny(values []string) string {
+	if len(values) == 0 {
+		return "(any)"
+	}
+	return strings.Join(values, ",")
+}
+func orNone(value string) string {
+	if len(value) == 0 {
+		return "-"
+	}
+	return value
+}
+func formatTimestamp(value time.Time) string {
+	if value.IsZero() {
+		return "-"
+	}
+	return value.Format(time.RFC3339)
+}
@@ -0,0 +1,168 @@
+package recaptcha
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"strings"
+)
+
+type ContentSecurityPolicy struct {
+	inner      http.Handler
+	directives []policyDirective
+	provider   string
+	header     string
+	random     func([]byte) (int, error)
+}
+
+func NewContentSecurityPolicy(options ...PolicyOption) *ContentSecurityPolicy {
+	this := &ContentSecurityPolicy{random: rand.Read}
+
+	WithPolicyHandler(http.NotFoundHandler())(this)
+	WithPolicyProvider(ProviderGoogle)(this)
+	WithBasePolicy(defaultBasePolicy)(this)
+	WithPolicyReportOnly(false)(this)
+
+	for _, option := range options {
+		option(this)
+	}
+
+	return this
+}
+
+func (this *ContentSecurityPolicy) Install(inner http.Handler) {
+	this.inner = inner
+}
+
+func (this *ContentSecurityPolicy) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	nonce, err := this.nonce()
+	if err != nil {
+		writeResponse(response, http.StatusInternalServerError)
+		return
+	}
+
+	response.Header().Set(this.header, this.Policy(nonce))
+	this.inner.ServeHTTP(response, request.WithContext(ContextWithNonce(request.Context(), nonce)))
+}
+func (this *ContentSecurityPolicy) nonce() (string, error) {
+	raw := make([]byte, nonceSize)
+	if _, err := this.random(raw); err != nil {
+		return "", err
+	}
+
+	return base64.RawStdEncoding.EncodeToString(raw), nil
+}
+
+func (this *ContentSecurityPolicy) Policy(nonce string) string {
+	directives := make([]policyDirective, 0, len(this.directives)+2)
+	directives = append(directives, this.directives...)
+
+	scriptSources := append([]string{"'nonce-" + nonce + "'"}, this.sources(providerScriptSources)...)
+	directives = mergeDirective(directives, scriptSrcDirective, scriptSources)
+	directives = mergeDirective(directives, frameSrcDirective, this.sources(providerFrameSources))
+
+	rendered := make([]string, 0, len(directives))
+	for _, directive := range directives {
+		rendered = append(rendered, strings.Join(append([]string{directive.name}, directive.sources...), " "))
+	}
+	return strings.Join(rendered, "; ")
+}
+
+func (this *ContentSecurityPolicy) sources(known map[string][]string) []string {
+	if sources, found := known[this.provider]; found {
+		return sources
+	}
+
+	return []string{this.provider + "/recaptcha/"}
+}
+
+type policyDirective struct {
+	name    string
+	sources []string
+}
+
+func parsePolicy(value string) (directives []policyDirective) {
+	for _, item := range strings.Split(value, ";") {
+		if fields := strings.Fields(item); len(fields) > 0 {
+			directives = append(directives, policyDirective{name: strings.ToLower(fields[0]), sources: fields[1:]})
+		}
+	}
+
+	return directives
+}
+func mergeDirective(directives []policyDirective, name string, sources []string) []policyDirective {
+	for i, directive := range directives {
+		if directive.name == name {
+			merged := append(append([]string(nil), directive.sources...), sources...)
+			directives[i] = policyDirective{name: name, sources: withoutNone(merged)}
+			return directives
+		}
+	}
+
+	return append(directives, policyDirective{name: name, sources: append([]string{"'self'"}, sources...)})
+}
+func withoutNone(sources []string) (filtered []string) {
+	for _, source := range sources {
+		if source != "'none'" {
+			filtered = append(filtered, source)
+		}
+	}
+
+	return filtered
+}
+
+func NonceFromContext(ctx context.Context) string {
+	nonce, _ := ctx.Value(nonceContextKey{}).(string)
+	return nonce
+}
+func ContextWithNonce(ctx context.Context, nonce string) context.Context {
+	return context.WithValue(ctx, nonceContextKey{}, nonce)
+}
+
+type nonceContextKey struct{}
+
+/* ------------------------------------------------------------------------------------------------------------------ */
+
+type PolicyOption func(*ContentSecurityPolicy)
+
+func WithPolicyHandler(value http.Handler) PolicyOption {
+	return func(this *ContentSecurityPolicy) { this.inner = value }
+}
+func WithPolicyProvider(value string) PolicyOption {
+	return func(this *ContentSecurityPolicy) { this.provider = strings.TrimSuffix(value, "/") }
+}
+func WithBasePolicy(value string) PolicyOption {
+	return func(this *ContentSecurityPolicy) { this.directives = parsePolicy(value) }
+}
+func WithPolicyReportOnly(value bool) PolicyOption {
+	return func(this *ContentSecurityPolicy) {
+		if value {
+			this.header = policyReportOnlyHeader
+		} else {
+			this.header = policyHeader
+		}
+	}
+}
+
+/* ------------------------------------------------------------------------------------------------------------------ */
+
+const (
+	policyHeader           = "Content-Security-Policy"
+	policyReportOnlyHeader = "Content-Security-Policy-Report-Only"
+	defaultBasePolicy      = "default-src 'self'; object-src 'none'; base-uri 'self'"
+	scriptSrcDirective     = "script-src"
+	frameSrcDirective      = "frame-src"
+	nonceSize              = 16
+)
+
+var (
+	providerScriptSources = map[string][]string{
+		ProviderGoogle:       {"https://www.google.com/recaptcha/", "https://www.gstatic.com/recaptcha/"},
+		ProviderRecaptchaNet: {"https://www.recaptcha.net/recaptcha/", "https://www.gstatic.com/recaptcha/"},
+	}
+	providerFrameSources = map[string][]string{
+		ProviderGoogle:       {"https://www.google.com/recaptcha/", "https://recaptcha.google.com/recaptcha/"},
+		ProviderRecaptchaNet: {"https://www.recaptcha.net/recaptcha/", "https://recaptcha.net/recaptcha/"},
+	}
+)
@@ -0,0 +1,102 @@
+package recaptcha
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+)
+
+func TestContentSecurityPolicyFixture(t *testing.T) {
+	gunit.Run(new(ContentSecurityPolicyFixture), t)
+}
+
+type ContentSecurityPolicyFixture struct {
+	*gunit.Fixture
+
+	policy   *ContentSecurityPolicy
+	response *httptest.ResponseRecorder
+	request  *http.Request
+	nonces   []string
+}
+
+func (this *ContentSecurityPolicyFixture) Setup() {
+	this.policy = NewContentSecurityPolicy(WithPolicyHandler(this))
+	this.policy.random = func(buffer []byte) (int, error) {
+		for i := range buffer {
+			buffer[i] = byte(len(this.nonces))
+		}
+		return len(buffer), nil
+	}
+	this.response = httptest.NewRecorder()
+	this.request = httptest.NewRequest(http.MethodGet, "/", nil)
+}
+
+func (this *ContentSecurityPolicyFixture) TestDefaultPolicy() {
+	this.So(this.policy.Policy("abc"), should.Equal, "default-src 'self'; object-src 'none'; base-uri 'self'; "+
+		"script-src 'self' 'nonce-abc' https://www.google.com/recaptcha/ https://www.gstatic.com/recaptcha/; "+
+		"frame-src 'self' https://www.google.com/recaptcha/ https://recaptcha.google.com/recaptcha/")
+}
+func (this *ContentSecurityPolicyFixture) TestMergedIntoBasePolicy() {
+	WithBasePolicy("default-src 'none'; script-src 'self' https://cdn.example.com; frame-src 'none'")(this.policy)
+	WithPolicyProvider(ProviderRecaptchaNet)(this.policy)
+
+	this.So(this.policy.Policy("abc"), should.Equal, "default-src 'none'; "+
+		"script-src 'self' https://cdn.example.com 'nonce-abc' https://www.recaptcha.net/recaptcha/ https://www.gstatic.com/recaptcha/; "+
+		"frame-src https://www.recaptcha.net/recaptcha/ https://recaptcha.net/recaptcha/")
+}
+func (this *ContentSecurityPolicyFixture) TestCustomProviderOrigin() {
+	WithBasePolicy("")(this.policy)
+	WithPolicyProvider("http://localhost:8080/")(this.policy)
+
+	this.So(this.policy.Policy("abc"), should.Equal,
+		"script-src 'self' 'nonce-abc' http://localhost:8080/recaptcha/; frame-src 'self' http://localhost:8080/recaptcha/")
+}
+func (this *ContentSecurityPolicyFixture) TestNonceSharedWithHeaderAndContext() {
+	this.policy.ServeHTTP(this.response, this.request)
+
+	this.So(this.nonces, should.Resemble, []string{"AAAAAAAAAAAAAAAAAAAAAA"})
+	this.So(this.response.Header().Get("Content-Security-Policy"), should.ContainSubstring, "'nonce-AAAAAAAAAAAAAAAAAAAAAA'")
+}
+func (this *ContentSecurityPolicyFixture) TestNonceChangesPerRequest() {
+	this.policy.ServeHTTP(httptest.NewRecorder(), this.request)
+	this.policy.ServeHTTP(httptest.NewRecorder(), this.request)
+
+	this.So(this.nonces[0], should.NotEqual, this.nonces[1])
+}
+func (this *ContentSecurityPolicyFixture) TestReportOnlyHeader() {
+	WithPolicyReportOnly(true)(this.policy)
+
+	this.policy.ServeHTTP(this.response, this.request)
+
+	this.So(this.response.Header().Get("Content-Security-Policy"), should.BeEmpty)
+	this.So(this.response.Header().Get("Content-Security-Policy-Report-Only"), should.NotBeEmpty)
+}
+func (this *ContentSecurityPolicyFixture) TestRandomFailure() {
+	this.policy.random = func([]byte) (int, error) { return 0, errors.New("") }
+
+	this.policy.ServeHTTP(this.response, this.request)
+
+	this.So(this.response.Code, should.Equal, http.StatusInternalServerError)
+	this.So(this.nonces, should.BeEmpty)
+}
+func (this *ContentSecurityPolicyFixture) TestWrapsDefaultHandler() {
+	handler := NewHandler(this, WithInnerHandler(this))
+	this.policy.Install(handler)
+	this.request.URL.RawQuery = DefaultFormTokenName + "=token"
+
+	this.policy.ServeHTTP(this.response, this.request)
+
+	this.So(this.nonces, should.HaveLength, 1)
+	this.So(this.response.Header().Get("Content-Security-Policy"), should.NotBeEmpty)
+}
+
+func (this *ContentSecurityPolicyFixture) ServeHTTP(_ http.ResponseWriter, request *http.Request) {
+	this.nonces = append(this.nonces, NonceFromContext(request.Context()))
+}
+func (this *ContentSecurityPolicyFixture) Verify(string, string) (bool, error) {
+	return true, nil
+}
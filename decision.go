@@ -0,0 +1,132 @@
+package recaptcha
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+type Decision struct {
+	Outcome   Outcome
+	Result    Result
+	Error     error
+	Duration  time.Duration
+	Timestamp time.Time
+	ClientIP  string
+	TokenHash string
+	Enforced  bool
+	Candidate Outcome
+
+	RetryAfter time.Duration
+}
+
+func newDecision(result Result, err error, duration time.Duration) Decision {
+	return Decision{Outcome: outcomeOf(result, err), Result: result, Error: err, Duration: duration}
+}
+func outcomeOf(result Result, err error) Outcome {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return OutcomeCanceled
+	} else if result.Accepted {
+		return OutcomeAccepted
+	} else if errors.Is(err, ErrLookupFailure) {
+		return OutcomeFailOpen
+	} else if err != nil && !errors.Is(err, ErrInvalidToken) {
+		return OutcomeError
+	} else {
+		return OutcomeRejected
+	}
+}
+
+func (this Decision) Disagrees() bool {
+	return len(this.Candidate) > 0 && this.Candidate != this.Outcome
+}
+
+func (this Decision) consulted() bool {
+	switch this.Outcome {
+	case OutcomeAllowlisted, OutcomeDenylisted, OutcomeThrottled:
+		return false
+	default:
+		return this.Result.Reason != ReasonMissingToken && !this.Result.Bypass
+	}
+}
+func (this Decision) answered() bool {
+	return this.consulted() && this.Error == nil && (this.Outcome == OutcomeAccepted || this.Outcome == OutcomeRejected)
+}
+
+func (this Decision) Reason() string {
+	if code := this.ErrorCode(); len(code) > 0 {
+		return code
+	}
+
+	return this.Result.Reason
+}
+
+func (this Decision) ErrorCode() string {
+	var verification *VerificationError
+
+	if this.Error == nil {
+		return ""
+	} else if errors.As(this.Error, &verification) {
+		return strings.Join(verification.Codes, ",")
+	}
+
+	for _, item := range errorCodes {
+		if errors.Is(this.Error, item.err) {
+			return item.code
+		}
+	}
+
+	return "unknown"
+}
+
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{err: ErrUpstreamUnavailable, code: "upstream-unavailable"},
+	{err: ErrMalformedResponse, code: "malformed-response"},
+	{err: ErrUpstreamRejected, code: "upstream-rejected"},
+	{err: ErrLookupFailure, code: "lookup-failure"},
+	{err: ErrInvalidToken, code: "invalid-token"},
+	{err: ErrServerConfig, code: "server-config"},
+	{err: context.Canceled, code: "canceled"},
+	{err: context.DeadlineExceeded, code: "deadline-exceeded"},
+}
+
+func DecisionFromContext(ctx context.Context) (Decision, bool) {
+	decision, ok := ctx.Value(decisionContextKey{}).(Decision)
+	return decision, ok
+}
+func contextWithDecision(ctx context.Context, decision Decision) context.Context {
+	return context.WithValue(ctx, decisionContextKey{}, decision)
+}
+
+type decisionContextKey struct{}
+
+func hashToken(token string) string {
+	if len(token) == 0 {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+/* ------------------------------------------------------------------------------------------------------------------ */
+
+type Outcome string
+
+const (
+	OutcomeAccepted Outcome = "accepted"
+	OutcomeRejected Outcome = "rejected"
+	OutcomeError    Outcome = "error"
+	OutcomeFailOpen Outcome = "fail-open"
+
+	OutcomeAllowlisted Outcome = "allowlisted"
+	OutcomeDenylisted  Outcome = "denylisted"
+	OutcomeThrottled   Outcome = "throttled"
+	OutcomeCanceled    Outcome = "canceled"
+)
@@ -0,0 +1,65 @@
+package recaptcha
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+)
+
+func TestDecisionFixture(t *testing.T) {
+	gunit.Run(new(DecisionFixture), t)
+}
+
+type DecisionFixture struct {
+	*gunit.Fixture
+}
+
+func (this *DecisionFixture) TestOutcomes() {
+	this.So(newDecision(Result{Accepted: true}, nil, 0).Outcome, should.Equal, OutcomeAccepted)
+	this.So(newDecision(Result{}, nil, 0).Outcome, should.Equal, OutcomeRejected)
+	this.So(newDecision(Result{}, &VerificationError{Codes: []string{ErrorCodeDuplicate}}, 0).Outcome, should.Equal, OutcomeRejected)
+	this.So(newDecision(Result{}, ErrUpstreamUnavailable, 0).Outcome, should.Equal, OutcomeFailOpen)
+	this.So(newDecision(Result{}, ErrServerConfig, 0).Outcome, should.Equal, OutcomeError)
+	this.So(newDecision(Result{}, errors.New("unknown"), 0).Outcome, should.Equal, OutcomeError)
+}
+func (this *DecisionFixture) TestErrorCode() {
+	this.So(Decision{}.ErrorCode(), should.BeEmpty)
+	this.So(Decision{Error: &VerificationError{Codes: []string{"a", "b"}}}.ErrorCode(), should.Equal, "a,b")
+	this.So(Decision{Error: ErrUpstreamUnavailable}.ErrorCode(), should.Equal, "upstream-unavailable")
+	this.So(Decision{Error: ErrMalformedResponse}.ErrorCode(), should.Equal, "malformed-response")
+	this.So(Decision{Error: ErrUpstreamRejected}.ErrorCode(), should.Equal, "upstream-rejected")
+	this.So(Decision{Error: ErrLookupFailure}.ErrorCode(), should.Equal, "lookup-failure")
+	this.So(Decision{Error: ErrServerConfig}.ErrorCode(), should.Equal, "server-config")
+	this.So(Decision{Error: errors.New("")}.ErrorCode(), should.Equal, "unknown")
+}
+func (this *DecisionFixture) TestDisagrees() {
+	this.So(Decision{Outcome: OutcomeAccepted}.Disagrees(), should.BeFalse)
+	this.So(Decision{Outcome: OutcomeAccepted, Candidate: OutcomeAccepted}.Disagrees(), should.BeFalse)
+	this.So(Decision{Outcome: OutcomeAccepted, Candidate: OutcomeRejected}.Disagrees(), should.BeTrue)
+}
+func (this *DecisionFixture) TestDecisionCarriedInContext() {
+	decision := Decision{Outcome: OutcomeRejected, ClientIP: "1.2.3.4"}
+
+	_, found := DecisionFromContext(context.Background())
+	carried, ok := DecisionFromContext(contextWithDecision(context.Background(), decision))
+
+	this.So(found, should.BeFalse)
+	this.So(ok, should.BeTrue)
+	this.So(carried, should.Resemble, decision)
+}
+func (this *DecisionFixture) TestObserversNotifiedInOrder() {
+	var notified []int
+	first := observerFunc(func(Decision) { notified = append(notified, 1) })
+	second := observerFunc(func(Decision) { notified = append(notified, 2) })
+
+	observers{first, second}.Observe(Decision{})
+
+	this.So(notified, should.Resemble, []int{1, 2})
+}
+
+type observerFunc func(Decision)
+
+func (this observerFunc) Observe(decision Decision) { this(decision) }
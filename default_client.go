@@ -0,0 +1,40 @@
+package recaptcha
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+func newDefaultHTTPClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   defaultDialTimeout,
+		KeepAlive: defaultKeepAlive,
+	}
+
+	return &http.Client{
+		Timeout: defaultRequestTimeout,
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   defaultTLSHandshakeTimeout,
+			ResponseHeaderTimeout: defaultResponseHeaderTimeout,
+			ExpectContinueTimeout: defaultExpectContinueTimeout,
+			IdleConnTimeout:       defaultIdleConnTimeout,
+			MaxIdleConns:          defaultMaxIdleConns,
+			MaxIdleConnsPerHost:   defaultMaxIdleConns, // all traffic goes to the single siteverify host
+			ForceAttemptHTTP2:     true,
+		},
+	}
+}
+
+const (
+	defaultDialTimeout           = time.Second * 2
+	defaultKeepAlive             = time.Second * 30
+	defaultTLSHandshakeTimeout   = time.Second * 2
+	defaultResponseHeaderTimeout = time.Second * 3
+	defaultExpectContinueTimeout = time.Second
+	defaultIdleConnTimeout       = time.Second * 90
+	defaultRequestTimeout        = time.Second * 5
+	defaultMaxIdleConns          = 64
+)
@@ -0,0 +1,45 @@
+package recaptcha
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+)
+
+func TestDefaultClientFixture(t *testing.T) {
+	gunit.Run(new(DefaultClientFixture), t)
+}
+
+type DefaultClientFixture struct {
+	*gunit.Fixture
+}
+
+func (this *DefaultClientFixture) TestClientHasOverallTimeout() {
+	client := newDefaultHTTPClient()
+
+	this.So(client.Timeout, should.Equal, defaultRequestTimeout)
+}
+func (this *DefaultClientFixture) TestTransportTimeouts() {
+	transport := newDefaultHTTPClient().Transport.(*http.Transport)
+
+	this.So(transport.DialContext, should.NotBeNil)
+	this.So(transport.TLSHandshakeTimeout, should.Equal, defaultTLSHandshakeTimeout)
+	this.So(transport.ResponseHeaderTimeout, should.Equal, defaultResponseHeaderTimeout)
+	this.So(transport.IdleConnTimeout, should.Equal, defaultIdleConnTimeout)
+}
+func (this *DefaultClientFixture) TestTransportPoolsConnectionsToSingleHost() {
+	transport := newDefaultHTTPClient().Transport.(*http.Transport)
+
+	this.So(transport.MaxIdleConns, should.Equal, defaultMaxIdleConns)
+	this.So(transport.MaxIdleConnsPerHost, should.Equal, defaultMaxIdleConns)
+	this.So(transport.ForceAttemptHTTP2, should.BeTrue)
+}
+func (this *DefaultClientFixture) TestEachClientHasItsOwnTransport() {
+	first := newDefaultHTTPClient()
+	second := newDefaultHTTPClient()
+
+	this.So(first.Transport, should.NotPointTo, second.Transport)
+	this.So(first.Transport, should.NotPointTo, http.DefaultTransport)
+}
@@ -1,14 +1,34 @@
 package recaptcha
 
-import "net/http"
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
 
 type DefaultHandler struct {
-	inner          http.Handler
-	verifier       TokenVerifier
-	token          func(*http.Request) string
-	clientIP       func(*http.Request) string
-	rejectedStatus int
-	errorStatus    int
+	inner           http.Handler
+	verifier        TokenVerifier
+	token           func(*http.Request) string
+	clientIP        func(*http.Request) string
+	rejectedStatus  int
+	errorStatus     int
+	observer        Observer
+	tracer          Tracer
+	audit           AuditSink
+	redactions      []AuditRedaction
+	reportOnly      bool
+	candidate       Policy
+	allowed         networkList
+	denied          networkList
+	limiter         RateLimiter
+	limitAction     func(*http.Request) string
+	rejectionCost   float64
+	throttledStatus int
+	forwardAuth     bool
+	trustedProxies  networkList
 }
 
 func NewHandler(verifier TokenVerifier, options ...HandlerOption) *DefaultHandler {
@@ -18,6 +38,17 @@ func NewHandler(verifier TokenVerifier, options ...HandlerOption) *DefaultHandle
 	WithClientIPReader(defaultClientIPReader)(this)
 	WithRejectedStatus(defaultRejectedStatus)(this)
 	WithErrorStatus(defaultErrorStatus)(this)
+	WithObserver()(this)
+	WithHandlerTracer(nopTracer{})(this)
+	WithAuditSink(nopAuditSink{})(this)
+	WithReportOnly(false)(this)
+	WithCandidatePolicy(nil)(this)
+	WithAllowedNetworks()(this)
+	WithDeniedNetworks()(this)
+	WithRateLimiter(nil)(this)
+	WithRateLimitAction(nil)(this)
+	WithRejectionCost(defaultRejectionCost)(this)
+	WithThrottledStatus(defaultThrottledStatus)(this)
 
 	for _, option := range options {
 		option(this)
@@ -31,24 +62,150 @@ func (this *DefaultHandler) Install(inner http.Handler) {
 }
 
 func (this *DefaultHandler) ServeHTTP(response http.ResponseWriter, request *http.Request) {
-	result, err := this.verify(request)
+	token := this.readToken(request)
+	ctx, decision := this.decide(request, token)
+	this.observer.Observe(decision)
+	this.audit.Record(newAuditRecord(decision, this.redactions))
+	request = request.WithContext(contextWithDecision(ctx, decision))
+	if this.forwardAuth {
+		writeDecisionHeaders(response.Header(), decision)
+	}
+
+	if decision.Outcome == OutcomeCanceled {
+		writeResponse(response, this.rejectedStatus)
+		return
+	} else if !decision.Enforced {
+		this.forward(response, request)
+		return
+	}
 
-	if result || err == ErrLookupFailure {
+	switch decision.Outcome {
+	case OutcomeAccepted, OutcomeFailOpen, OutcomeAllowlisted:
+		this.forward(response, request)
+	case OutcomeError:
+		writeResponse(response, this.deniedStatus(this.errorStatus))
+	case OutcomeThrottled:
+		response.Header().Set(retryAfterHeader, formatRetryAfter(decision.RetryAfter))
+		writeResponse(response, this.deniedStatus(this.throttledStatus))
+	default:
+		writeResponse(response, this.rejectedStatus)
+	}
+}
+func (this *DefaultHandler) forward(response http.ResponseWriter, request *http.Request) {
+	if this.forwardAuth {
+		forwardAuthAccepted(response, request)
+	} else {
 		this.inner.ServeHTTP(response, request)
-	} else if err != nil {
-		writeResponse(response, this.errorStatus)
+	}
+}
+func (this *DefaultHandler) deniedStatus(status int) int {
+	if this.forwardAuth {
+		return this.rejectedStatus // auth_request only understands 2xx, 401 and 403; the reason travels in headers
+	}
+	return status
+}
+func (this *DefaultHandler) readToken(request *http.Request) string {
+	if this.forwardAuth {
+		return forwardAuthToken(request, this.token)
+	}
+	return this.token(request)
+}
+func (this *DefaultHandler) readClientIP(request *http.Request) string {
+	if this.forwardAuth {
+		return forwardAuthClientIP(request, this.trustedProxies, this.clientIP)
+	}
+	return this.clientIP(request)
+}
+func (this *DefaultHandler) decide(request *http.Request, token string) (context.Context, Decision) {
+	ctx, span := this.tracer.Start(request.Context(), SpanDecision)
+	defer span.End()
+
+	clientIP := this.readClientIP(request)
+
+	started := time.Now()
+	limitKey := this.rateLimitKey(request, clientIP)
+	decision := this.screen(clientIP)
+	if len(decision.Outcome) == 0 {
+		decision = this.throttle(limitKey)
+	}
+	if len(decision.Outcome) == 0 {
+		result, err := verifyContext(ctx, this.verifier, token, clientIP)
+		if canceled := request.Context().Err(); canceled != nil {
+			result, err = Result{}, canceled
+		}
+		decision = newDecision(result, err, time.Since(started))
+		this.penalize(limitKey, decision)
+	}
+
+	decision.Timestamp = started
+	decision.ClientIP = clientIP
+	decision.TokenHash = hashToken(token)
+	decision.Enforced = !this.reportOnly
+	decision.Candidate = this.evaluateCandidate(decision)
+
+	traceResult(span, decision.Result, decision.Error)
+	span.SetAttribute(AttributeOutcome, string(decision.Outcome))
+	return ctx, decision
+}
+func (this *DefaultHandler) screen(clientIP string) Decision {
+	address := parseClientIP(clientIP)
+
+	if this.denied.contains(address) {
+		return Decision{Outcome: OutcomeDenylisted, Result: Result{Reason: ReasonClientDenylisted}}
+	} else if this.allowed.contains(address) {
+		return Decision{Outcome: OutcomeAllowlisted, Result: Result{Reason: ReasonClientAllowlisted}}
 	} else {
-		writeResponse(response, this.rejectedStatus)
+		return Decision{}
+	}
+}
+func (this *DefaultHandler) throttle(key string) Decision {
+	if this.limiter == nil {
+		return Decision{}
+	} else if retryAfter, allowed := this.limiter.Reserve(key, acceptedCost); allowed {
+		return Decision{}
+	} else {
+		return Decision{Outcome: OutcomeThrottled, Result: Result{Reason: ReasonRateLimited}, RetryAfter: retryAfter}
 	}
 }
-func (this *DefaultHandler) verify(request *http.Request) (bool, error) {
-	token := this.token(request)
-	clientIP := this.clientIP(request)
-	return this.verifier.Verify(token, clientIP)
+func (this *DefaultHandler) penalize(key string, decision Decision) {
+	if this.limiter != nil && decision.Outcome == OutcomeRejected && this.rejectionCost > 0 {
+		this.limiter.Charge(key, this.rejectionCost)
+	}
+}
+func (this *DefaultHandler) rateLimitKey(request *http.Request, clientIP string) string {
+	if this.limiter == nil {
+		return ""
+	}
+
+	key := clientIP
+	if address := parseClientIP(clientIP); address != nil {
+		key = address.String()
+	}
+
+	if this.limitAction != nil {
+		key += "|" + this.limitAction(request)
+	}
+
+	return key
+}
+
+func (this *DefaultHandler) evaluateCandidate(decision Decision) Outcome {
+	if this.candidate == nil {
+		return ""
+	} else if decision.Error != nil || (decision.Outcome != OutcomeAccepted && decision.Outcome != OutcomeRejected) {
+		return decision.Outcome
+	} else if this.candidate.Accepts(decision.Result) {
+		return OutcomeAccepted
+	} else {
+		return OutcomeRejected
+	}
 }
 func writeResponse(response http.ResponseWriter, statusCode int) {
 	http.Error(response, http.StatusText(statusCode), statusCode)
 }
+func formatRetryAfter(value time.Duration) string {
+	return strconv.Itoa(int(math.Max(1, math.Ceil(value.Seconds()))))
+}
 
 /* ------------------------------------------------------------------------------------------------------------------ */
 
@@ -69,10 +226,56 @@ func WithErrorStatus(value int) HandlerOption {
 func WithInnerHandler(value http.Handler) HandlerOption {
 	return func(this *DefaultHandler) { this.inner = value }
 }
+func WithObserver(values ...Observer) HandlerOption {
+	return func(this *DefaultHandler) { this.observer = observers(values) }
+}
+func WithHandlerTracer(value Tracer) HandlerOption {
+	return func(this *DefaultHandler) { this.tracer = value }
+}
+func WithAuditSink(value AuditSink, redactions ...AuditRedaction) HandlerOption {
+	return func(this *DefaultHandler) { this.audit = value; this.redactions = redactions }
+}
+func WithReportOnly(value bool) HandlerOption {
+	return func(this *DefaultHandler) { this.reportOnly = value }
+}
+func WithCandidatePolicy(value Policy) HandlerOption {
+	return func(this *DefaultHandler) { this.candidate = value }
+}
+func WithRateLimiter(value RateLimiter) HandlerOption {
+	return func(this *DefaultHandler) { this.limiter = value }
+}
+func WithRateLimitAction(callback func(*http.Request) string) HandlerOption {
+	return func(this *DefaultHandler) { this.limitAction = callback }
+}
+func WithRejectionCost(value float64) HandlerOption {
+	return func(this *DefaultHandler) { this.rejectionCost = value }
+}
+func WithThrottledStatus(value int) HandlerOption {
+	return func(this *DefaultHandler) { this.throttledStatus = value }
+}
+func WithAllowedNetworks(values ...string) HandlerOption {
+	networks := parseNetworks(values)
+	return func(this *DefaultHandler) { this.allowed = networks }
+}
+func WithDeniedNetworks(values ...string) HandlerOption {
+	networks := parseNetworks(values)
+	return func(this *DefaultHandler) { this.denied = networks }
+}
+func WithForwardAuth(trustedProxies ...string) HandlerOption {
+	networks := parseNetworks(trustedProxies)
+	return func(this *DefaultHandler) { this.forwardAuth = true; this.trustedProxies = networks }
+}
 
 func defaultTokenReader(request *http.Request) string {
 	return request.URL.Query().Get(DefaultFormTokenName)
 }
+
+func HeaderTokenReader(name string) func(*http.Request) string {
+	return func(request *http.Request) string { return request.Header.Get(name) }
+}
+func FormTokenReader(name string) func(*http.Request) string {
+	return func(request *http.Request) string { return request.FormValue(name) }
+}
 func defaultClientIPReader(request *http.Request) string {
 	return request.RemoteAddr
 }
@@ -83,4 +286,9 @@ const (
 	DefaultFormTokenName  = "g-recaptcha-response"
 	defaultRejectedStatus = http.StatusForbidden
 	defaultErrorStatus    = http.StatusInternalServerError
+
+	defaultThrottledStatus = http.StatusTooManyRequests
+	defaultRejectionCost   = 4
+	acceptedCost           = 1
+	retryAfterHeader       = "Retry-After"
 )
@@ -1,10 +1,13 @@
 package recaptcha
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/smartystreets/assertions/should"
 	"github.com/smartystreets/gunit"
@@ -30,12 +33,14 @@ type DefaultHandlerFixture struct {
 	verifiedClientIP string
 	verifyResult     bool
 	verifyError      error
+
+	observed []Decision
 }
 
 func (this *DefaultHandlerFixture) Setup() {
 	this.request, _ = http.NewRequest(http.MethodGet, "/some-path/", nil)
 	this.response = httptest.NewRecorder()
-	this.handler = NewHandler(this)
+	this.handler = NewHandler(this, WithObserver(this))
 	this.handler.Install(this)
 	this.verifyResult = true
 }
@@ -65,6 +70,25 @@ func (this *DefaultHandlerFixture) TestConfigurationErrorRequestRejected() {
 	this.assertResponse(defaultErrorStatus)
 }
 
+func (this *DefaultHandlerFixture) TestInvalidTokenRequestRejected() {
+	this.verifyResult = false
+	this.verifyError = &VerificationError{Codes: []string{ErrorCodeDuplicate}}
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.assertInnerNotCalled()
+	this.assertResponse(defaultRejectedStatus)
+}
+func (this *DefaultHandlerFixture) TestSecretErrorUsesErrorStatus() {
+	this.verifyResult = false
+	this.verifyError = &VerificationError{Codes: []string{ErrorCodeInvalidSecret}}
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.assertInnerNotCalled()
+	this.assertResponse(defaultErrorStatus)
+}
+
 func (this *DefaultHandlerFixture) TestLookupFailureRequestAllowed() {
 	this.verifyResult = false
 	this.verifyError = ErrLookupFailure
@@ -74,6 +98,49 @@ func (this *DefaultHandlerFixture) TestLookupFailureRequestAllowed() {
 	this.assertInnerCalled()
 }
 
+func (this *DefaultHandlerFixture) TestCanceledRequestNeverReachesInnerHandler() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	this.request = this.request.WithContext(ctx)
+	this.verifyResult = false
+	this.verifyError = ErrLookupFailure
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.assertInnerNotCalled()
+	this.So(this.response.Code, should.Equal, http.StatusForbidden)
+	this.So(this.observed[0].Outcome, should.Equal, OutcomeCanceled)
+	this.So(this.observed[0].ErrorCode(), should.Equal, "canceled")
+}
+func (this *DefaultHandlerFixture) TestCanceledRequestNotForwardedInReportOnlyMode() {
+	WithReportOnly(true)(this.handler)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	this.request = this.request.WithContext(ctx)
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.assertInnerNotCalled()
+}
+
+func (this *DefaultHandlerFixture) TestWrappedLookupFailureRequestAllowed() {
+	this.verifyResult = false
+	this.verifyError = ErrUpstreamUnavailable
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.assertInnerCalled()
+}
+func (this *DefaultHandlerFixture) TestWrappedConfigurationErrorRequestRejected() {
+	this.verifyResult = false
+	this.verifyError = ErrUpstreamRejected
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.assertInnerNotCalled()
+	this.assertResponse(defaultErrorStatus)
+}
+
 func (this *DefaultHandlerFixture) TestTokenAndClientIPReadFromRequest() {
 	this.request, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("/?%s=my-token", DefaultFormTokenName), nil)
 	this.request.RemoteAddr = "1.2.3.4"
@@ -127,10 +194,301 @@ func (this *DefaultHandlerFixture) TestAlternateErrorResponseStatus() {
 	this.assertResponse(http.StatusBadGateway)
 }
 
+func (this *DefaultHandlerFixture) TestDecisionsObserved() {
+	this.handler.ServeHTTP(this.response, this.request)
+	this.verifyResult = false
+	this.handler.ServeHTTP(this.response, this.request)
+	this.verifyError = ErrLookupFailure
+	this.handler.ServeHTTP(this.response, this.request)
+	this.verifyError = ErrServerConfig
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.So(this.observed, should.HaveLength, 4)
+	this.So(this.observed[0].Outcome, should.Equal, OutcomeAccepted)
+	this.So(this.observed[0].Result, should.Resemble, Result{Accepted: true})
+	this.So(this.observed[1].Outcome, should.Equal, OutcomeRejected)
+	this.So(this.observed[2].Outcome, should.Equal, OutcomeFailOpen)
+	this.So(this.observed[2].Error, should.Equal, ErrLookupFailure)
+	this.So(this.observed[3].Outcome, should.Equal, OutcomeError)
+}
+func (this *DefaultHandlerFixture) TestDetailedResultObserved() {
+	verifier := &contextVerifierFake{result: Result{Accepted: true, Score: 0.9, Action: "login"}}
+	handler := NewHandler(verifier, WithObserver(this), WithInnerHandler(this))
+	this.request = this.request.WithContext(context.WithValue(this.request.Context(), testContextKey{}, "value"))
+
+	handler.ServeHTTP(this.response, this.request)
+
+	this.So(verifier.ctx.Value(testContextKey{}), should.Equal, "value")
+	this.So(this.observed, should.HaveLength, 1)
+	this.So(this.observed[0].Result, should.Resemble, verifier.result)
+	this.assertInnerCalled()
+}
+
+func (this *DefaultHandlerFixture) TestDecisionTraced() {
+	tracer := new(recordingTracer)
+	verifier := &contextVerifierFake{result: Result{Score: 0.1, Action: "login"}}
+	handler := NewHandler(verifier, WithHandlerTracer(tracer), WithInnerHandler(this))
+	this.request = this.request.WithContext(context.WithValue(this.request.Context(), testContextKey{}, "value"))
+
+	handler.ServeHTTP(this.response, this.request)
+
+	this.So(tracer.spans, should.HaveLength, 1)
+	span := tracer.spans[0]
+	this.So(span.name, should.Equal, SpanDecision)
+	this.So(span.parent.Value(testContextKey{}), should.Equal, "value")
+	this.So(span.ended, should.BeTrue)
+	this.So(span.attributes[AttributeOutcome], should.Equal, string(OutcomeRejected))
+	this.So(span.attributes[AttributeScore], should.Equal, float64(float32(0.1)))
+	this.So(span.attributes[AttributeAction], should.Equal, "login")
+	this.So(verifier.ctx.Value(recordedSpanKey{}), should.Equal, span)
+}
+func (this *DefaultHandlerFixture) TestInnerHandlerReceivesDecisionSpanContext() {
+	tracer := new(recordingTracer)
+	verifier := &contextVerifierFake{result: Result{Accepted: true, Score: 0.9}}
+	handler := NewHandler(verifier, WithHandlerTracer(tracer), WithInnerHandler(this))
+	this.request = this.request.WithContext(context.WithValue(this.request.Context(), testContextKey{}, "value"))
+
+	handler.ServeHTTP(this.response, this.request)
+
+	this.assertInnerCalled()
+	this.So(this.innerRequest.Context().Value(recordedSpanKey{}), should.Equal, tracer.spans[0])
+	this.So(this.innerRequest.Context().Value(testContextKey{}), should.Equal, "value")
+	decision, found := DecisionFromContext(this.innerRequest.Context())
+	this.So(found, should.BeTrue)
+	this.So(decision.Outcome, should.Equal, OutcomeAccepted)
+}
+
+func (this *DefaultHandlerFixture) TestDecisionAudited() {
+	var records []AuditRecord
+	sink := auditSinkFunc(func(record AuditRecord) { records = append(records, record) })
+	WithAuditSink(sink, RedactHostname)(this.handler)
+	this.request, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("/?%s=token", DefaultFormTokenName), nil)
+	this.request.RemoteAddr = "1.2.3.4"
+	this.verifyResult = false
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.So(records, should.HaveLength, 1)
+	this.So(records[0].Timestamp, should.NotBeZeroValue)
+	this.So(records[0].ClientIP, should.Equal, "1.2.3.4")
+	this.So(records[0].TokenHash, should.Equal, hashToken("token"))
+	this.So(records[0].Decision, should.Equal, OutcomeRejected)
+	this.So(this.observed[0].ClientIP, should.Equal, "1.2.3.4")
+	this.So(this.observed[0].TokenHash, should.Equal, hashToken("token"))
+}
+
+func (this *DefaultHandlerFixture) TestDecisionAvailableToInnerHandler() {
+	this.handler.ServeHTTP(this.response, this.request)
+
+	decision, found := DecisionFromContext(this.innerRequest.Context())
+	this.So(found, should.BeTrue)
+	this.So(decision.Outcome, should.Equal, OutcomeAccepted)
+	this.So(decision.Enforced, should.BeTrue)
+}
+func (this *DefaultHandlerFixture) TestSpentTokenNotExposedForForwarding() {
+	this.request.URL.RawQuery = DefaultFormTokenName + "=token"
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.So(TokenFromContext(this.innerRequest.Context()), should.BeEmpty)
+}
+func (this *DefaultHandlerFixture) TestHeaderTokenReader() {
+	this.request.Header.Set("X-Recaptcha-Token", "header-token")
+
+	this.So(HeaderTokenReader("X-Recaptcha-Token")(this.request), should.Equal, "header-token")
+}
+func (this *DefaultHandlerFixture) TestFormTokenReader() {
+	this.request, _ = http.NewRequest(http.MethodPost, "/", strings.NewReader("field=form-token"))
+	this.request.Header.Set(contentTypeHeader, defaultContentType)
+
+	this.So(FormTokenReader("field")(this.request), should.Equal, "form-token")
+}
+func (this *DefaultHandlerFixture) TestReportOnlyAlwaysCallsInnerHandler() {
+	WithReportOnly(true)(this.handler)
+	this.verifyResult = false
+	this.verifyError = ErrServerConfig
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.assertInnerCalled()
+	decision, _ := DecisionFromContext(this.innerRequest.Context())
+	this.So(decision.Outcome, should.Equal, OutcomeError)
+	this.So(decision.Enforced, should.BeFalse)
+	this.So(this.observed[0].Enforced, should.BeFalse)
+}
+func (this *DefaultHandlerFixture) TestCandidatePolicyDisagreement() {
+	verifier := &contextVerifierFake{result: Result{Accepted: true, Score: 0.5}}
+	handler := NewHandler(verifier, WithInnerHandler(this), WithObserver(this),
+		WithCandidatePolicy(NewPolicy(WithRequiredThreshold(0.7))))
+
+	handler.ServeHTTP(this.response, this.request)
+
+	this.assertInnerCalled()
+	this.So(this.observed[0].Outcome, should.Equal, OutcomeAccepted)
+	this.So(this.observed[0].Candidate, should.Equal, OutcomeRejected)
+	this.So(this.observed[0].Disagrees(), should.BeTrue)
+}
+func (this *DefaultHandlerFixture) TestCandidatePolicyAgreement() {
+	verifier := &contextVerifierFake{result: Result{Score: 0.1}}
+	handler := NewHandler(verifier, WithInnerHandler(this), WithObserver(this),
+		WithCandidatePolicy(NewPolicy(WithRequiredThreshold(0.7))))
+
+	handler.ServeHTTP(this.response, this.request)
+
+	this.assertInnerNotCalled()
+	this.So(this.observed[0].Candidate, should.Equal, OutcomeRejected)
+	this.So(this.observed[0].Disagrees(), should.BeFalse)
+}
+func (this *DefaultHandlerFixture) TestCandidatePolicyMirrorsErrors() {
+	verifier := &contextVerifierFake{err: ErrUpstreamUnavailable}
+	handler := NewHandler(verifier, WithInnerHandler(this), WithObserver(this),
+		WithCandidatePolicy(NewPolicy(WithRequiredThreshold(0))))
+
+	handler.ServeHTTP(this.response, this.request)
+
+	this.So(this.observed[0].Candidate, should.Equal, OutcomeFailOpen)
+}
+
+func (this *DefaultHandlerFixture) TestAllowlistedClientSkipsVerification() {
+	WithAllowedNetworks("192.0.2.0/24")(this.handler)
+	this.request.RemoteAddr = "192.0.2.10:1234"
+	this.verifyResult = false
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.assertInnerCalled()
+	this.So(this.verifiedClientIP, should.BeEmpty)
+	decision, _ := DecisionFromContext(this.innerRequest.Context())
+	this.So(decision.Outcome, should.Equal, OutcomeAllowlisted)
+	this.So(decision.Reason(), should.Equal, ReasonClientAllowlisted)
+}
+func (this *DefaultHandlerFixture) TestDenylistedClientRejectedWithoutVerification() {
+	WithDeniedNetworks("2001:db8::/32")(this.handler)
+	this.request.RemoteAddr = "[2001:db8::1]:443"
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.assertInnerNotCalled()
+	this.assertResponse(defaultRejectedStatus)
+	this.So(this.verifiedClientIP, should.BeEmpty)
+	this.So(this.observed[0].Outcome, should.Equal, OutcomeDenylisted)
+}
+func (this *DefaultHandlerFixture) TestDenylistTakesPrecedence() {
+	WithAllowedNetworks("192.0.2.0/24")(this.handler)
+	WithDeniedNetworks("192.0.2.10")(this.handler)
+	this.request.RemoteAddr = "192.0.2.10:1234"
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.assertInnerNotCalled()
+	this.So(this.observed[0].Outcome, should.Equal, OutcomeDenylisted)
+}
+func (this *DefaultHandlerFixture) TestUnlistedClientVerified() {
+	WithAllowedNetworks("192.0.2.0/24")(this.handler)
+	WithDeniedNetworks("198.51.100.0/24")(this.handler)
+	this.request.RemoteAddr = "203.0.113.1:1234"
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.So(this.verifiedClientIP, should.Equal, "203.0.113.1:1234")
+	this.So(this.observed[0].Outcome, should.Equal, OutcomeAccepted)
+}
+func (this *DefaultHandlerFixture) TestScreenedClientsNotComparedToCandidate() {
+	WithDeniedNetworks("192.0.2.0/24")(this.handler)
+	WithCandidatePolicy(NewPolicy(WithRequiredThreshold(0)))(this.handler)
+	this.request.RemoteAddr = "192.0.2.10:1234"
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.So(this.observed[0].Candidate, should.Equal, OutcomeDenylisted)
+	this.So(this.observed[0].Disagrees(), should.BeFalse)
+}
+func (this *DefaultHandlerFixture) TestMalformedNetworkOptionPanics() {
+	this.So(func() { WithAllowedNetworks("bad") }, should.Panic)
+	this.So(func() { WithDeniedNetworks("10.0.0.0/33") }, should.Panic)
+}
+
+func (this *DefaultHandlerFixture) TestThrottledRequestNotVerified() {
+	limiter := &rateLimiterFake{retryAfter: time.Millisecond * 1500}
+	WithRateLimiter(limiter)(this.handler)
+	this.request.RemoteAddr = "192.0.2.1:1234"
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.assertInnerNotCalled()
+	this.assertResponse(http.StatusTooManyRequests)
+	this.So(this.response.Header().Get("Retry-After"), should.Equal, "2")
+	this.So(this.verifiedClientIP, should.BeEmpty)
+	this.So(limiter.reserved, should.Resemble, []string{"192.0.2.1"})
+	this.So(this.observed[0].Outcome, should.Equal, OutcomeThrottled)
+	this.So(this.observed[0].RetryAfter, should.Equal, time.Millisecond*1500)
+}
+func (this *DefaultHandlerFixture) TestAlternateThrottledStatus() {
+	WithRateLimiter(&rateLimiterFake{})(this.handler)
+	WithThrottledStatus(http.StatusServiceUnavailable)(this.handler)
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.assertResponse(http.StatusServiceUnavailable)
+	this.So(this.response.Header().Get("Retry-After"), should.Equal, "1")
+}
+func (this *DefaultHandlerFixture) TestRejectedVerificationCharged() {
+	limiter := &rateLimiterFake{allowed: true}
+	WithRateLimiter(limiter)(this.handler)
+	WithRejectionCost(3)(this.handler)
+	this.request.RemoteAddr = "192.0.2.1:1234"
+	this.verifyResult = false
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.assertResponse(defaultRejectedStatus)
+	this.So(limiter.charged, should.Resemble, map[string]float64{"192.0.2.1": 3})
+}
+func (this *DefaultHandlerFixture) TestAcceptedVerificationNotCharged() {
+	limiter := &rateLimiterFake{allowed: true}
+	WithRateLimiter(limiter)(this.handler)
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.assertInnerCalled()
+	this.So(limiter.charged, should.BeEmpty)
+}
+func (this *DefaultHandlerFixture) TestRateLimitKeyedByAction() {
+	limiter := &rateLimiterFake{allowed: true}
+	WithRateLimiter(limiter)(this.handler)
+	WithRateLimitAction(func(request *http.Request) string { return request.URL.Path })(this.handler)
+	this.request.RemoteAddr = "[2001:db8::1]:443"
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.So(limiter.reserved, should.Resemble, []string{"2001:db8::1|/some-path/"})
+}
+func (this *DefaultHandlerFixture) TestAllowlistedClientsNotRateLimited() {
+	limiter := &rateLimiterFake{}
+	WithRateLimiter(limiter)(this.handler)
+	WithAllowedNetworks("192.0.2.0/24")(this.handler)
+	this.request.RemoteAddr = "192.0.2.1:1234"
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.assertInnerCalled()
+	this.So(limiter.reserved, should.BeEmpty)
+}
+func (this *DefaultHandlerFixture) TestTokenBucketLimiterWiredIn() {
+	WithRateLimiter(NewTokenBucketLimiter(0.001, 5))(this.handler)
+	this.verifyResult = false
+
+	this.handler.ServeHTTP(httptest.NewRecorder(), this.request)
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.assertResponse(http.StatusTooManyRequests)
+}
+
 /* ------------------------------------------------------------------------------------------------------------------ */
 
 func (this *DefaultHandlerFixture) assertInnerCalled() {
-	this.So(this.innerRequest, should.Equal, this.request)
+	this.So(this.innerRequest, should.NotBeNil)
+	this.So(this.innerRequest.URL, should.Equal, this.request.URL)
 	this.So(this.innerResponse, should.Equal, this.response)
 	this.So(this.innerCalls, should.Equal, 1)
 }
@@ -152,8 +510,50 @@ func (this *DefaultHandlerFixture) Verify(token, clientIP string) (bool, error)
 	return this.verifyResult, this.verifyError
 }
 
+func (this *DefaultHandlerFixture) Observe(decision Decision) {
+	this.observed = append(this.observed, decision)
+}
+
 func (this *DefaultHandlerFixture) ServeHTTP(response http.ResponseWriter, request *http.Request) {
 	this.innerRequest = request
 	this.innerResponse = response
 	this.innerCalls++
 }
+
+type testContextKey struct{}
+
+type rateLimiterFake struct {
+	allowed    bool
+	retryAfter time.Duration
+	reserved   []string
+	charged    map[string]float64
+}
+
+func (this *rateLimiterFake) Reserve(key string, _ float64) (time.Duration, bool) {
+	this.reserved = append(this.reserved, key)
+	return this.retryAfter, this.allowed
+}
+func (this *rateLimiterFake) Charge(key string, cost float64) {
+	if this.charged == nil {
+		this.charged = map[string]float64{}
+	}
+	this.charged[key] += cost
+}
+
+type auditSinkFunc func(AuditRecord)
+
+func (this auditSinkFunc) Record(record AuditRecord) { this(record) }
+
+type contextVerifierFake struct {
+	ctx    context.Context
+	result Result
+	err    error
+}
+
+func (this *contextVerifierFake) Verify(string, string) (bool, error) {
+	panic("VerifyContext should be preferred")
+}
+func (this *contextVerifierFake) VerifyContext(ctx context.Context, _, _ string) (Result, error) {
+	this.ctx = ctx
+	return this.result, this.err
+}
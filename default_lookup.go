@@ -1,27 +1,97 @@
 package recaptcha
 
+import "time"
+
 type defaultLookup struct {
-	Score    float32  `json:"score"`
-	Action   string   `json:"action"`
-	Hostname string   `json:"hostname"`
-	Errors   []string `json:"errors"`
+	Score           float32                   `json:"score"`
+	Action          string                    `json:"action"`
+	Hostname        string                    `json:"hostname"`
+	ApkPackageName  string                    `json:"apk_package_name"`
+	TokenProperties enterpriseTokenProperties `json:"tokenProperties"`
+	RiskAnalysis    enterpriseRiskAnalysis    `json:"riskAnalysis"`
+	ChallengeTS     time.Time                 `json:"challenge_ts"`
+	Errors          []string                  `json:"error-codes"`
+}
+
+type enterpriseTokenProperties struct {
+	Valid              *bool     `json:"valid"`
+	InvalidReason      string    `json:"invalidReason"`
+	Hostname           string    `json:"hostname"`
+	Action             string    `json:"action"`
+	CreateTime         time.Time `json:"createTime"`
+	AndroidPackageName string    `json:"androidPackageName"`
+	IOSBundleID        string    `json:"iosBundleId"`
+}
+
+type enterpriseRiskAnalysis struct {
+	Score float32 `json:"score"`
+}
+
+func (this *defaultLookup) normalize() {
+	properties := this.TokenProperties
+	if this.Score == 0 {
+		this.Score = this.RiskAnalysis.Score
+	}
+	if len(this.Action) == 0 {
+		this.Action = properties.Action
+	}
+	if len(this.Hostname) == 0 {
+		this.Hostname = properties.Hostname
+	}
+	if this.ChallengeTS.IsZero() {
+		this.ChallengeTS = properties.CreateTime
+	}
+	if properties.Valid != nil && !*properties.Valid && len(this.Errors) == 0 {
+		this.Errors = []string{enterpriseErrorCode(properties.InvalidReason)}
+	}
+}
+func enterpriseErrorCode(invalidReason string) string {
+	switch invalidReason {
+	case "EXPIRED", "DUPE":
+		return ErrorCodeDuplicate
+	case "MISSING":
+		return ErrorCodeMissingResponse
+	default:
+		return ErrorCodeInvalidResponse
+	}
 }
 
-func (this defaultLookup) IsValid(allowedHosts, allowedActions map[string]struct{}, requiredThreshold float32) (bool, error) {
+func (this defaultLookup) IsValid(allowedOrigins allowedOrigins, allowedActions map[string]struct{}, requiredThreshold float32) (bool, error) {
 	result, err := this.tokenExists()
 	return result &&
 		this.meetsRequiredThreshold(requiredThreshold) &&
-		this.hasAllowedHost(allowedHosts) &&
+		this.hasAllowedOrigin(allowedOrigins) &&
 		this.hasAllowedAction(allowedActions), err
 }
 
-func (this defaultLookup) tokenExists() (bool, error) {
-	for _, item := range this.Errors {
-		if item == expiredTokenMessage {
-			return false, nil
-		}
+func (this defaultLookup) rejectionReason(allowedOrigins allowedOrigins, allowedActions map[string]struct{}, requiredThreshold float32) string {
+	if !this.meetsRequiredThreshold(requiredThreshold) {
+		return ReasonScoreBelowThreshold
+	} else if !this.hasAllowedOrigin(allowedOrigins) {
+		return this.originRejectionReason()
+	} else if !this.hasAllowedAction(allowedActions) {
+		return ReasonActionNotAllowed
+	} else {
+		return ""
+	}
+}
+
+func (this defaultLookup) result(accepted bool) Result {
+	return Result{
+		Accepted:           accepted,
+		Score:              this.Score,
+		Action:             this.Action,
+		Hostname:           this.Hostname,
+		AndroidPackageName: this.androidPackageName(),
+		IOSBundleID:        this.TokenProperties.IOSBundleID,
+		ChallengeTS:        this.ChallengeTS,
+		ErrorCodes:         this.Errors,
+	}
+}
 
-		return false, ErrServerConfig
+func (this defaultLookup) tokenExists() (bool, error) {
+	if len(this.Errors) > 0 {
+		return false, &VerificationError{Codes: this.Errors}
 	}
 
 	return true, nil
@@ -31,8 +101,30 @@ func (this defaultLookup) meetsRequiredThreshold(threshold float32) bool {
 	return this.Score >= threshold
 }
 
-func (this defaultLookup) hasAllowedHost(allowed map[string]struct{}) bool {
-	return isValueAllowed(this.Hostname, allowed)
+func (this defaultLookup) hasAllowedOrigin(allowed allowedOrigins) bool {
+	if name := this.androidPackageName(); len(name) > 0 {
+		return allowed.permits(name, allowed.packages)
+	} else if len(this.TokenProperties.IOSBundleID) > 0 {
+		return allowed.permits(this.TokenProperties.IOSBundleID, allowed.bundles)
+	} else {
+		return allowed.permitsHost(this.Hostname)
+	}
+}
+func (this defaultLookup) originRejectionReason() string {
+	if len(this.androidPackageName()) > 0 {
+		return ReasonPackageNotAllowed
+	} else if len(this.TokenProperties.IOSBundleID) > 0 {
+		return ReasonBundleNotAllowed
+	} else {
+		return ReasonHostNotAllowed
+	}
+}
+func (this defaultLookup) androidPackageName() string {
+	if len(this.ApkPackageName) > 0 {
+		return this.ApkPackageName
+	}
+
+	return this.TokenProperties.AndroidPackageName
 }
 
 func (this defaultLookup) hasAllowedAction(allowed map[string]struct{}) bool {
@@ -48,5 +140,35 @@ func isValueAllowed(value string, allowed map[string]struct{}) bool {
 	return found
 }
 
-// Error Code Reference: https://developers.google.com/recaptcha/docs/verify
-const expiredTokenMessage = "timeout-or-duplicate"
+type allowedOrigins struct {
+	hosts        map[string]struct{}
+	hostPatterns hostPatterns
+	packages     map[string]struct{}
+	bundles      map[string]struct{}
+}
+
+func (this allowedOrigins) permitsHost(hostname string) bool {
+	if len(this.hosts) == 0 && this.hostPatterns.empty() {
+		return !this.configured()
+	} else if _, found := this.hosts[hostname]; found {
+		return true
+	}
+
+	normalized := normalizeHost(hostname)
+	if _, found := this.hosts[normalized]; found {
+		return true
+	}
+
+	return this.hostPatterns.matches(normalized)
+}
+func (this allowedOrigins) permits(value string, allowed map[string]struct{}) bool {
+	if len(allowed) > 0 {
+		_, found := allowed[value]
+		return found
+	}
+
+	return !this.configured()
+}
+func (this allowedOrigins) configured() bool {
+	return len(this.hosts) > 0 || !this.hostPatterns.empty() || len(this.packages) > 0 || len(this.bundles) > 0
+}
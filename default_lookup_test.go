@@ -1,7 +1,9 @@
 package recaptcha
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/smartystreets/assertions/should"
 	"github.com/smartystreets/gunit"
@@ -18,7 +20,7 @@ type DefaultLookupFixture struct {
 func (this *DefaultLookupFixture) TestAcceptedWhenScoreMeetsThreshold() {
 	lookup := defaultLookup{Score: 0.5}
 
-	result, err := lookup.IsValid(nil, nil, 0.5)
+	result, err := lookup.IsValid(allowedOrigins{}, nil, 0.5)
 
 	this.So(result, should.BeTrue)
 	this.So(err, should.BeNil)
@@ -26,7 +28,7 @@ func (this *DefaultLookupFixture) TestAcceptedWhenScoreMeetsThreshold() {
 func (this *DefaultLookupFixture) TestRejectedWhenScoreDoesNotMeetThreshold() {
 	lookup := defaultLookup{Score: 0.5}
 
-	result, err := lookup.IsValid(nil, nil, lookup.Score+0.1)
+	result, err := lookup.IsValid(allowedOrigins{}, nil, lookup.Score+0.1)
 
 	this.So(result, should.BeFalse)
 	this.So(err, should.BeNil)
@@ -36,7 +38,7 @@ func (this *DefaultLookupFixture) TestRejectWhenRequiredHostMissing() {
 	lookup := defaultLookup{}
 	allowedHosts := map[string]struct{}{"some-hostname": {}}
 
-	result, err := lookup.IsValid(allowedHosts, nil, 0.0)
+	result, err := lookup.IsValid(allowedOrigins{hosts: allowedHosts}, nil, 0.0)
 
 	this.So(result, should.BeFalse)
 	this.So(err, should.BeNil)
@@ -45,7 +47,7 @@ func (this *DefaultLookupFixture) TestAcceptedWhenRequiredHostFound() {
 	lookup := defaultLookup{Hostname: "some-hostname"}
 	allowedHosts := map[string]struct{}{lookup.Hostname: {}}
 
-	result, err := lookup.IsValid(allowedHosts, nil, 0.0)
+	result, err := lookup.IsValid(allowedOrigins{hosts: allowedHosts}, nil, 0.0)
 
 	this.So(result, should.BeTrue)
 	this.So(err, should.BeNil)
@@ -55,7 +57,7 @@ func (this *DefaultLookupFixture) TestRejectWhenRequiredActionMissing() {
 	lookup := defaultLookup{}
 	allowedActions := map[string]struct{}{"some-action": {}}
 
-	result, err := lookup.IsValid(nil, allowedActions, 0.0)
+	result, err := lookup.IsValid(allowedOrigins{}, allowedActions, 0.0)
 
 	this.So(result, should.BeFalse)
 	this.So(err, should.BeNil)
@@ -64,27 +66,99 @@ func (this *DefaultLookupFixture) TestAcceptedWhenRequiredActionFound() {
 	lookup := defaultLookup{Action: "some-action"}
 	allowedActions := map[string]struct{}{lookup.Action: {}}
 
-	result, err := lookup.IsValid(nil, allowedActions, 0.0)
+	result, err := lookup.IsValid(allowedOrigins{}, allowedActions, 0.0)
 
 	this.So(result, should.BeTrue)
 	this.So(err, should.BeNil)
 }
 
 func (this *DefaultLookupFixture) TestRejectedWhenTokenExpired() {
-	lookup := defaultLookup{Errors: []string{expiredTokenMessage}}
+	lookup := defaultLookup{Errors: []string{ErrorCodeDuplicate}}
 
-	result, err := lookup.IsValid(nil, nil, 0.0)
+	result, err := lookup.IsValid(allowedOrigins{}, nil, 0.0)
 
 	this.So(result, should.BeFalse)
-	this.So(err, should.BeNil)
+	this.So(err, should.Wrap, ErrInvalidToken)
+	this.So(errors.Is(err, ErrServerConfig), should.BeFalse)
+}
+func (this *DefaultLookupFixture) TestRejectedWhenTokenInvalid() {
+	lookup := defaultLookup{Errors: []string{ErrorCodeInvalidResponse}}
+
+	result, err := lookup.IsValid(allowedOrigins{}, nil, 0.0)
+
+	this.So(result, should.BeFalse)
+	this.So(err, should.Wrap, ErrInvalidToken)
 }
 func (this *DefaultLookupFixture) TestServerErrors() {
 	lookup := defaultLookup{Errors: []string{"other-error"}}
 
-	result, err := lookup.IsValid(nil, nil, 0.0)
+	result, err := lookup.IsValid(allowedOrigins{}, nil, 0.0)
+
+	this.So(result, should.BeFalse)
+	this.So(err, should.Wrap, ErrServerConfig)
+}
+func (this *DefaultLookupFixture) TestSecretErrors() {
+	lookup := defaultLookup{Errors: []string{ErrorCodeInvalidSecret}}
+
+	result, err := lookup.IsValid(allowedOrigins{}, nil, 0.0)
+
+	var typed *VerificationError
+	this.So(result, should.BeFalse)
+	this.So(err, should.Wrap, ErrServerConfig)
+	this.So(errors.As(err, &typed), should.BeTrue)
+	this.So(typed.Codes, should.Resemble, []string{ErrorCodeInvalidSecret})
+}
+
+func (this *DefaultLookupFixture) TestAndroidPackageCheckedAgainstPackages() {
+	origins := allowedOrigins{
+		hosts:    map[string]struct{}{"some-hostname": {}},
+		packages: map[string]struct{}{"com.example.app": {}},
+	}
+
+	allowed, _ := defaultLookup{ApkPackageName: "com.example.app"}.IsValid(origins, nil, 0)
+	other, _ := defaultLookup{ApkPackageName: "com.example.other"}.IsValid(origins, nil, 0)
+	enterprise, _ := defaultLookup{TokenProperties: enterpriseTokenProperties{AndroidPackageName: "com.example.app"}}.IsValid(origins, nil, 0)
+	web, _ := defaultLookup{Hostname: "some-hostname"}.IsValid(origins, nil, 0)
+
+	this.So(allowed, should.BeTrue)
+	this.So(other, should.BeFalse)
+	this.So(enterprise, should.BeTrue)
+	this.So(web, should.BeTrue)
+}
+func (this *DefaultLookupFixture) TestIOSBundleCheckedAgainstBundles() {
+	origins := allowedOrigins{bundles: map[string]struct{}{"com.example.ios": {}}}
+
+	allowed, _ := defaultLookup{TokenProperties: enterpriseTokenProperties{IOSBundleID: "com.example.ios"}}.IsValid(origins, nil, 0)
+	other, _ := defaultLookup{TokenProperties: enterpriseTokenProperties{IOSBundleID: "com.example.other"}}.IsValid(origins, nil, 0)
+
+	this.So(allowed, should.BeTrue)
+	this.So(other, should.BeFalse)
+}
+func (this *DefaultLookupFixture) TestPlatformWithoutAllowlistRejectedWhenAnotherConfigured() {
+	origins := allowedOrigins{hosts: map[string]struct{}{"some-hostname": {}}}
+	lookup := defaultLookup{ApkPackageName: "com.example.app"}
+
+	result, _ := lookup.IsValid(origins, nil, 0)
 
 	this.So(result, should.BeFalse)
-	this.So(err, should.Equal, ErrServerConfig)
+	this.So(lookup.rejectionReason(origins, nil, 0), should.Equal, ReasonPackageNotAllowed)
+	this.So(defaultLookup{TokenProperties: enterpriseTokenProperties{IOSBundleID: "x"}}.rejectionReason(origins, nil, 0), should.Equal, ReasonBundleNotAllowed)
+}
+func (this *DefaultLookupFixture) TestAnyPlatformAcceptedWithoutAllowlists() {
+	result, _ := defaultLookup{ApkPackageName: "com.example.app"}.IsValid(allowedOrigins{}, nil, 0)
+
+	this.So(result, should.BeTrue)
+}
+
+func (this *DefaultLookupFixture) TestRejectionReason() {
+	allowedHosts := map[string]struct{}{"some-hostname": {}}
+	allowedActions := map[string]struct{}{"some-action": {}}
+	lookup := defaultLookup{Score: 0.5, Hostname: "some-hostname", Action: "some-action"}
+
+	this.So(lookup.rejectionReason(allowedOrigins{hosts: allowedHosts}, allowedActions, 0.6), should.Equal, ReasonScoreBelowThreshold)
+	this.So(defaultLookup{Score: 0.5, Action: "some-action"}.rejectionReason(allowedOrigins{hosts: allowedHosts}, allowedActions, 0.5), should.Equal, ReasonHostNotAllowed)
+	this.So(defaultLookup{Score: 0.5, Hostname: "some-hostname"}.rejectionReason(allowedOrigins{hosts: allowedHosts}, allowedActions, 0.5), should.Equal, ReasonActionNotAllowed)
+	this.So(lookup.rejectionReason(allowedOrigins{hosts: allowedHosts}, allowedActions, 0.5), should.BeEmpty)
 }
 
 func (this *DefaultLookupFixture) TestFullValidation() {
@@ -103,8 +177,51 @@ func (this *DefaultLookupFixture) TestFullValidation() {
 		"another-action": {},
 	}
 
-	result, err := lookup.IsValid(allowedHosts, allowedActions, lookup.Score)
+	result, err := lookup.IsValid(allowedOrigins{hosts: allowedHosts}, allowedActions, lookup.Score)
 
 	this.So(result, should.BeTrue)
 	this.So(err, should.BeNil)
 }
+
+func (this *DefaultLookupFixture) TestEnterpriseFieldsNormalized() {
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	valid := true
+	lookup := defaultLookup{
+		RiskAnalysis:    enterpriseRiskAnalysis{Score: 0.7},
+		TokenProperties: enterpriseTokenProperties{Valid: &valid, Hostname: "example.com", Action: "login", CreateTime: created},
+	}
+
+	lookup.normalize()
+
+	this.So(lookup.Score, should.Equal, float32(0.7))
+	this.So(lookup.Hostname, should.Equal, "example.com")
+	this.So(lookup.Action, should.Equal, "login")
+	this.So(lookup.ChallengeTS, should.Equal, created)
+	this.So(lookup.Errors, should.BeEmpty)
+}
+func (this *DefaultLookupFixture) TestStandardFieldsKeptWhenNormalized() {
+	lookup := defaultLookup{Score: 0.9, Action: "login", Hostname: "example.com"}
+
+	lookup.normalize()
+
+	this.So(lookup, should.Resemble, defaultLookup{Score: 0.9, Action: "login", Hostname: "example.com"})
+}
+func (this *DefaultLookupFixture) TestEnterpriseInvalidReasonsMappedToErrorCodes() {
+	for reason, code := range map[string]string{
+		"MALFORMED":              ErrorCodeInvalidResponse,
+		"BROWSER_ERROR":          ErrorCodeInvalidResponse,
+		"UNKNOWN_INVALID_REASON": ErrorCodeInvalidResponse,
+		"EXPIRED":                ErrorCodeDuplicate,
+		"DUPE":                   ErrorCodeDuplicate,
+		"MISSING":                ErrorCodeMissingResponse,
+	} {
+		invalid := false
+		lookup := defaultLookup{TokenProperties: enterpriseTokenProperties{Valid: &invalid, InvalidReason: reason}}
+
+		lookup.normalize()
+		_, err := lookup.IsValid(allowedOrigins{}, nil, 0)
+
+		this.So(lookup.Errors, should.Resemble, []string{code})
+		this.So(err, should.Wrap, ErrInvalidToken)
+	}
+}
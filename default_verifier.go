@@ -1,11 +1,14 @@
 package recaptcha
 
 import (
+	"context"
 	"encoding/json"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 )
 
 type DefaultVerifier struct {
@@ -13,19 +16,29 @@ type DefaultVerifier struct {
 	client    httpClient
 	endpoint  string
 	threshold float32
-	hosts     map[string]struct{}
+	origins   allowedOrigins
 	actions   map[string]struct{}
+	tracer    Tracer
+	bypass    []byte
+	adaptive  *AdaptiveThreshold
+	cache     *ResultCache
+	flights   *flightGroup
+	siteKey   string
+	provider  string
+	now       func() time.Time
 }
 
 func NewVerifier(options ...VerifierOption) *DefaultVerifier {
-	this := &DefaultVerifier{}
+	this := &DefaultVerifier{now: time.Now, flights: newFlightGroup(defaultRequestTimeout)}
 
 	WithSecret(func() string { return "" })(this)
-	WithHTTPClient(http.DefaultClient)(this)
+	WithHTTPClient(newDefaultHTTPClient())(this)
 	WithEndpoint(defaultEndpoint)(this)
 	WithRequiredThreshold(defaultThreshold)(this)
 	WithAllowedHosts()(this)
 	WithAllowedActions()(this)
+	WithVerifierTracer(nopTracer{})(this)
+	WithWidgetProvider(ProviderGoogle)(this)
 
 	for _, option := range options {
 		option(this)
@@ -35,25 +48,102 @@ func NewVerifier(options ...VerifierOption) *DefaultVerifier {
 }
 
 func (this *DefaultVerifier) Verify(token, clientIP string) (bool, error) {
+	result, err := this.VerifyContext(context.Background(), token, clientIP)
+	return result.Accepted, err
+}
+func (this *DefaultVerifier) VerifyContext(ctx context.Context, token, clientIP string) (Result, error) {
 	token = strings.TrimSpace(token)
 	if len(token) == 0 {
-		return false, nil
+		return Result{Reason: ReasonMissingToken}, nil
+	} else if len(this.bypass) > 0 && strings.HasPrefix(token, bypassTokenPrefix) {
+		return this.verifyBypass(token)
+	}
+
+	result, err := this.flights.Do(ctx, this.flightKey(token, clientIP), func(ctx context.Context) (Result, error) {
+		if this.cache == nil {
+			return this.verify(ctx, token, clientIP)
+		}
+		return this.verifyCached(ctx, token, clientIP)
+	})
+	if canceled := ctx.Err(); canceled != nil {
+		return Result{}, canceled
 	}
+	return result, err
+}
+func (this *DefaultVerifier) flightKey(token, clientIP string) string {
+	return token + "\n" + clientIP
+}
+func (this *DefaultVerifier) verifyCached(ctx context.Context, token, clientIP string) (Result, error) {
+	if result, found := this.cache.Load(token, clientIP); found {
+		return result, nil
+	}
+
+	result, err := this.verify(ctx, token, clientIP)
+	if err == nil {
+		this.cache.Store(token, clientIP, result)
+	}
+	return result, err
+}
+func (this *DefaultVerifier) verify(ctx context.Context, token, clientIP string) (Result, error) {
+	ctx, span := this.tracer.Start(ctx, SpanSiteverify)
+	defer span.End()
 
-	return this.verify(token, clientIP)
+	result, err := this.lookup(ctx, token, clientIP)
+	traceResult(span, result, err)
+	span.SetAttribute(AttributeAttempts, 1+this.flights.waiters(this.flightKey(token, clientIP)))
+	return result, err
 }
-func (this *DefaultVerifier) verify(token, clientIP string) (bool, error) {
-	if response, err := this.newRequest(token, clientIP); err != nil {
-		return false, ErrLookupFailure
+func (this *DefaultVerifier) lookup(ctx context.Context, token, clientIP string) (Result, error) {
+	if response, err := this.newRequest(ctx, token, clientIP); err != nil {
+		return Result{}, ErrLookupFailure
 	} else if lookup, err := this.parseLookup(response); err != nil {
-		return false, ErrLookupFailure
+		return Result{}, err
 	} else {
-		return lookup.IsValid(this.hosts, this.actions, this.threshold)
+		threshold := this.thresholdFor(lookup.Action)
+		accepted, err := lookup.IsValid(this.origins, this.actions, threshold)
+		result := lookup.result(accepted)
+		if err == nil {
+			result.Reason = lookup.rejectionReason(this.origins, this.actions, threshold)
+			this.adapt(result)
+		}
+		return result, err
+	}
+}
+func (this *DefaultVerifier) thresholdFor(action string) float32 {
+	if this.adaptive == nil {
+		return this.threshold
+	}
+
+	return this.adaptive.Threshold(action)
+}
+func (this *DefaultVerifier) adapt(result Result) {
+	if this.adaptive != nil {
+		this.adaptive.Record(result.Action, result.Score, result.Accepted)
+	}
+}
+func (this *DefaultVerifier) verifyBypass(token string) (Result, error) {
+	claims, err := parseBypassToken(this.bypass, token, this.now())
+	if err != nil {
+		return Result{}, err
+	}
+
+	lookup := defaultLookup{Score: *claims.Score, Action: claims.Action}
+	actions := this.actions
+	if len(claims.Action) == 0 {
+		actions = nil
 	}
+
+	result := lookup.result(lookup.meetsRequiredThreshold(this.threshold) && lookup.hasAllowedAction(actions))
+	result.Bypass = true
+	result.Reason = ReasonBypassToken
+	if !result.Accepted {
+		result.Reason = lookup.rejectionReason(allowedOrigins{}, actions, this.threshold)
+	}
+	return result, nil
 }
-func (this *DefaultVerifier) newRequest(token, clientIP string) (*http.Response, error) {
+func (this *DefaultVerifier) newRequest(ctx context.Context, token, clientIP string) (*http.Response, error) {
 	body := this.buildRequestBody(token, clientIP)
-	request, _ := http.NewRequest(http.MethodPost, this.endpoint, body)
+	request, _ := http.NewRequestWithContext(ctx, http.MethodPost, this.endpoint, body)
 	request.Header.Set(contentTypeHeader, defaultContentType)
 	return this.client.Do(request)
 }
@@ -70,8 +160,35 @@ func (this *DefaultVerifier) buildRequestBody(token, clientIP string) io.Reader
 	return strings.NewReader(values.Encode())
 }
 func (this *DefaultVerifier) parseLookup(response *http.Response) (lookup defaultLookup, err error) {
-	defer func() { _ = response.Body.Close() }()
-	return lookup, json.NewDecoder(response.Body).Decode(&lookup)
+	defer drainAndClose(response.Body)
+
+	if response.StatusCode >= http.StatusInternalServerError {
+		return lookup, ErrUpstreamUnavailable
+	} else if response.StatusCode >= http.StatusBadRequest {
+		return lookup, ErrUpstreamRejected
+	} else if response.StatusCode != http.StatusOK || !isJSONContentType(response.Header.Get(contentTypeHeader)) {
+		return lookup, ErrMalformedResponse
+	}
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxResponseBodySize+1))
+	if err != nil {
+		return lookup, ErrLookupFailure
+	} else if len(body) > maxResponseBodySize {
+		return lookup, ErrMalformedResponse
+	} else if err = json.Unmarshal(body, &lookup); err != nil {
+		return lookup, ErrMalformedResponse
+	}
+
+	lookup.normalize()
+	return lookup, nil
+}
+func isJSONContentType(value string) bool {
+	mediaType, _, _ := mime.ParseMediaType(value)
+	return mediaType == jsonContentType || strings.HasSuffix(mediaType, jsonContentTypeSuffix)
+}
+func drainAndClose(body io.ReadCloser) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(body, maxDrainedBodySize))
+	_ = body.Close()
 }
 
 /* ------------------------------------------------------------------------------------------------------------------ */
@@ -91,11 +208,37 @@ func WithRequiredThreshold(value float32) VerifierOption {
 	return func(this *DefaultVerifier) { this.threshold = value }
 }
 func WithAllowedHosts(values ...string) VerifierOption {
-	return func(this *DefaultVerifier) { this.hosts = createMap(values) }
+	hosts, patterns := parseHosts(values)
+	return func(this *DefaultVerifier) { this.origins.hosts, this.origins.hostPatterns = hosts, patterns }
+}
+func WithAllowedAndroidPackages(values ...string) VerifierOption {
+	return func(this *DefaultVerifier) { this.origins.packages = createMap(values) }
+}
+func WithAllowedIOSBundles(values ...string) VerifierOption {
+	return func(this *DefaultVerifier) { this.origins.bundles = createMap(values) }
 }
 func WithAllowedActions(values ...string) VerifierOption {
 	return func(this *DefaultVerifier) { this.actions = createMap(values) }
 }
+func WithVerifierTracer(value Tracer) VerifierOption {
+	return func(this *DefaultVerifier) { this.tracer = value }
+}
+func WithSiteKey(value string) VerifierOption {
+	return func(this *DefaultVerifier) { this.siteKey = value }
+}
+func WithWidgetProvider(value string) VerifierOption {
+	return func(this *DefaultVerifier) { this.provider = strings.TrimSuffix(value, "/") }
+}
+func WithAdaptiveThreshold(value *AdaptiveThreshold) VerifierOption {
+	return func(this *DefaultVerifier) { this.adaptive = value }
+}
+func WithResultCache(value *ResultCache) VerifierOption {
+	return func(this *DefaultVerifier) { this.cache = value }
+}
+func WithBypassKey(key []byte) VerifierOption {
+	requireBypassKey(key)
+	return func(this *DefaultVerifier) { this.bypass = key }
+}
 func createMap(values []string) map[string]struct{} {
 	allowed := make(map[string]struct{}, len(values))
 	for _, value := range values {
@@ -105,10 +248,15 @@ func createMap(values []string) map[string]struct{} {
 }
 
 const (
-	contentTypeHeader  = "Content-Type"
-	defaultContentType = "application/x-www-form-urlencoded"
-	defaultEndpoint    = "https://www.google.com/recaptcha/api/siteverify"
-	defaultThreshold   = 0.3
+	contentTypeHeader     = "Content-Type"
+	defaultContentType    = "application/x-www-form-urlencoded"
+	jsonContentType       = "application/json"
+	jsonContentTypeSuffix = "+json"
+	defaultEndpoint       = "https://www.google.com/recaptcha/api/siteverify"
+	defaultThreshold      = 0.3
+
+	maxResponseBodySize = 1024 * 16
+	maxDrainedBodySize  = 1024 * 64
 )
 
 /* ------------------------------------------------------------------------------------------------------------------ */
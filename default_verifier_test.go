@@ -2,11 +2,14 @@ package recaptcha
 
 import (
 	"bytes"
+	"context"
 	"errors"
-	"io/ioutil"
+	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/smartystreets/assertions/should"
 	"github.com/smartystreets/gunit"
@@ -23,27 +26,44 @@ type DefaultVerifierFixture struct {
 
 	clientCalls          int
 	clientRequest        *http.Request
+	clientRequestErr     error
 	clientResponse       *http.Response
 	clientError          error
 	clientResponseBuffer *bytes.Buffer
+	clientResponseClosed bool
+	clientDone           chan struct{}
 }
 
 func (this *DefaultVerifierFixture) Setup() {
 	this.clientResponseBuffer = bytes.NewBuffer(nil)
 	this.clientResponse = &http.Response{
-		Body: ioutil.NopCloser(this.clientResponseBuffer),
+		StatusCode: http.StatusOK,
+		Header:     http.Header{contentTypeHeader: []string{"application/json; charset=utf-8"}},
+		Body:       &trackingBody{Reader: this.clientResponseBuffer, closed: &this.clientResponseClosed},
 	}
 
 	this.verifier = NewVerifier()
 	WithHTTPClient(this)(this.verifier)
 }
 
+func (this *DefaultVerifierFixture) TestDefaultHTTPClientHasTimeout() {
+	client := NewVerifier().client.(*http.Client)
+
+	this.So(client, should.NotPointTo, http.DefaultClient)
+	this.So(client.Timeout, should.Equal, defaultRequestTimeout)
+}
 func (this *DefaultVerifierFixture) TestEmptyTokenIsInvalid() {
 	result, err := this.verifier.Verify("", "")
 
 	this.So(result, should.BeFalse)
 	this.So(err, should.BeNil)
 }
+func (this *DefaultVerifierFixture) TestEmptyTokenReason() {
+	result, _ := this.verifier.VerifyContext(context.Background(), " ", "")
+
+	this.So(result.Reason, should.Equal, ReasonMissingToken)
+	this.So(this.clientCalls, should.Equal, 0)
+}
 func (this *DefaultVerifierFixture) TestTokenLookupIsPerformed() {
 	WithSecret(func() string { return "my-secret" })(this.verifier)
 
@@ -92,7 +112,63 @@ func (this *DefaultVerifierFixture) TestParsingError() {
 	result, err := this.verifier.Verify("token", "ip")
 
 	this.So(result, should.BeFalse)
-	this.So(err, should.Equal, ErrLookupFailure)
+	this.So(err, should.Equal, ErrMalformedResponse)
+	this.So(err, should.Wrap, ErrLookupFailure)
+}
+func (this *DefaultVerifierFixture) TestUnexpectedContentType() {
+	this.clientResponse.Header.Set(contentTypeHeader, "text/html")
+	this.writeResponseBody(`{"Score":1.0}`)
+
+	result, err := this.verifier.Verify("token", "ip")
+
+	this.So(result, should.BeFalse)
+	this.So(err, should.Equal, ErrMalformedResponse)
+}
+func (this *DefaultVerifierFixture) TestOversizedResponseBody() {
+	this.writeResponseBody(`{"Score":1.0,"action":"` + strings.Repeat("a", maxResponseBodySize) + `"}`)
+
+	result, err := this.verifier.Verify("token", "ip")
+
+	this.So(result, should.BeFalse)
+	this.So(err, should.Equal, ErrMalformedResponse)
+}
+func (this *DefaultVerifierFixture) TestUpstreamServerError() {
+	this.clientResponse.StatusCode = http.StatusBadGateway
+	this.clientResponse.Header.Set(contentTypeHeader, "text/html")
+	this.writeResponseBody("<html>Bad Gateway</html>")
+
+	result, err := this.verifier.Verify("token", "ip")
+
+	this.So(result, should.BeFalse)
+	this.So(err, should.Equal, ErrUpstreamUnavailable)
+	this.So(err, should.Wrap, ErrLookupFailure)
+}
+func (this *DefaultVerifierFixture) TestUpstreamClientError() {
+	this.clientResponse.StatusCode = http.StatusNotFound
+	this.writeResponseBody(`{}`)
+
+	result, err := this.verifier.Verify("token", "ip")
+
+	this.So(result, should.BeFalse)
+	this.So(err, should.Equal, ErrUpstreamRejected)
+	this.So(err, should.Wrap, ErrServerConfig)
+}
+func (this *DefaultVerifierFixture) TestUnexpectedStatus() {
+	this.clientResponse.StatusCode = http.StatusNoContent
+
+	result, err := this.verifier.Verify("token", "ip")
+
+	this.So(result, should.BeFalse)
+	this.So(err, should.Equal, ErrMalformedResponse)
+}
+func (this *DefaultVerifierFixture) TestResponseBodyDrainedAndClosed() {
+	this.clientResponse.StatusCode = http.StatusServiceUnavailable
+	this.writeResponseBody("unread content")
+
+	_, _ = this.verifier.Verify("token", "ip")
+
+	this.So(this.clientResponseBuffer.Len(), should.Equal, 0)
+	this.So(this.clientResponseClosed, should.BeTrue)
 }
 
 func (this *DefaultVerifierFixture) TestValidLookup() {
@@ -104,6 +180,166 @@ func (this *DefaultVerifierFixture) TestValidLookup() {
 	this.So(err, should.BeNil)
 }
 
+func (this *DefaultVerifierFixture) TestErrorCodesDecoded() {
+	this.writeResponseBody(`{"success":false,"error-codes":["timeout-or-duplicate"]}`)
+
+	result, err := this.verifier.Verify("token", "ip")
+
+	var typed *VerificationError
+	this.So(result, should.BeFalse)
+	this.So(err, should.Wrap, ErrInvalidToken)
+	this.So(errors.As(err, &typed), should.BeTrue)
+	this.So(typed.Codes, should.Resemble, []string{ErrorCodeDuplicate})
+}
+
+func (this *DefaultVerifierFixture) TestDetailedResult() {
+	this.writeResponseBody(`{
+		"success": true,
+		"score": 0.9,
+		"action": "login",
+		"hostname": "example.com",
+		"challenge_ts": "2020-01-02T03:04:05Z"
+	}`)
+
+	result, err := this.verifier.VerifyContext(context.Background(), "token", "ip")
+
+	this.So(err, should.BeNil)
+	this.So(result, should.Resemble, Result{
+		Accepted:    true,
+		Score:       0.9,
+		Action:      "login",
+		Hostname:    "example.com",
+		ChallengeTS: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+	})
+}
+func (this *DefaultVerifierFixture) TestRequestCarriesContext() {
+	ctx := context.WithValue(context.Background(), testContextKey{}, "value")
+
+	_, _ = this.verifier.VerifyContext(ctx, "token", "ip")
+
+	this.So(this.clientRequest.Context().Value(testContextKey{}), should.Equal, "value")
+}
+
+func (this *DefaultVerifierFixture) TestLookupDetachedFromCallerCancellation() {
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), testContextKey{}, "value"))
+	cancel()
+	this.writeResponseBody(`{"success":true,"score":0.9}`)
+	this.clientDone = make(chan struct{})
+
+	result, err := this.verifier.VerifyContext(ctx, "token", "ip")
+	<-this.clientDone
+
+	_, bounded := this.clientRequest.Context().Deadline()
+	this.So(this.clientRequestErr, should.BeNil)
+	this.So(bounded, should.BeTrue)
+	this.So(this.clientRequest.Context().Value(testContextKey{}), should.Equal, "value")
+	this.So(result, should.Resemble, Result{})
+	this.So(err, should.Equal, context.Canceled)
+}
+
+func (this *DefaultVerifierFixture) TestOutboundCallTraced() {
+	tracer := new(recordingTracer)
+	WithVerifierTracer(tracer)(this.verifier)
+	this.writeResponseBody(`{"score": 0.5, "action": "login", "hostname": "example.com"}`)
+	ctx := context.WithValue(context.Background(), testContextKey{}, "value")
+
+	_, _ = this.verifier.VerifyContext(ctx, "token", "ip")
+
+	this.So(tracer.spans, should.HaveLength, 1)
+	span := tracer.spans[0]
+	this.So(span.name, should.Equal, SpanSiteverify)
+	this.So(span.parent.Value(testContextKey{}), should.Equal, "value")
+	this.So(span.ended, should.BeTrue)
+	this.So(span.attributes, should.Resemble, map[string]interface{}{
+		AttributeAttempts: 1,
+		AttributeAccepted: true,
+		AttributeScore:    0.5,
+		AttributeAction:   "login",
+		AttributeHostname: "example.com",
+	})
+	this.So(this.clientRequest.Context().Value(recordedSpanKey{}), should.Equal, span)
+}
+func (this *DefaultVerifierFixture) TestFailedOutboundCallTraced() {
+	tracer := new(recordingTracer)
+	WithVerifierTracer(tracer)(this.verifier)
+	this.clientError = errors.New("")
+
+	_, _ = this.verifier.Verify("token", "ip")
+
+	this.So(tracer.spans[0].errors, should.Resemble, []error{ErrLookupFailure})
+}
+func (this *DefaultVerifierFixture) TestEmptyTokenNotTraced() {
+	tracer := new(recordingTracer)
+	WithVerifierTracer(tracer)(this.verifier)
+
+	_, _ = this.verifier.Verify("", "ip")
+
+	this.So(tracer.spans, should.BeEmpty)
+}
+
+func (this *DefaultVerifierFixture) TestBypassTokensIgnoredByDefault() {
+	key := []byte(strings.Repeat("k", minimumBypassKeyLength))
+	token := NewBypassToken(key, BypassClaims{Expires: time.Now().Add(time.Minute)})
+	this.writeResponseBody(`{"error-codes":["invalid-input-response"]}`)
+
+	result, err := this.verifier.Verify(token, "ip")
+
+	this.So(result, should.BeFalse)
+	this.So(err, should.Wrap, ErrInvalidToken)
+	this.So(this.clientCalls, should.Equal, 1)
+}
+func (this *DefaultVerifierFixture) TestBypassTokenVerifiedLocally() {
+	key := []byte(strings.Repeat("k", minimumBypassKeyLength))
+	WithBypassKey(key)(this.verifier)
+	WithAllowedHosts("example.com")(this.verifier)
+	WithAllowedActions("login")(this.verifier)
+	token := NewBypassToken(key, BypassClaims{Expires: time.Now().Add(time.Minute), Action: "login", Score: BypassScore(0.9)})
+
+	result, err := this.verifier.VerifyContext(context.Background(), token, "ip")
+
+	this.So(err, should.BeNil)
+	this.So(this.clientCalls, should.Equal, 0)
+	this.So(result, should.Resemble, Result{Accepted: true, Score: 0.9, Action: "login", Bypass: true, Reason: ReasonBypassToken})
+}
+func (this *DefaultVerifierFixture) TestBypassTokenWithoutActionAllowsAnyAction() {
+	key := []byte(strings.Repeat("k", minimumBypassKeyLength))
+	WithBypassKey(key)(this.verifier)
+	WithAllowedActions("login")(this.verifier)
+
+	result, _ := this.verifier.Verify(NewBypassToken(key, BypassClaims{Expires: time.Now().Add(time.Minute)}), "ip")
+
+	this.So(result, should.BeTrue)
+}
+func (this *DefaultVerifierFixture) TestBypassTokenSubjectToPolicy() {
+	key := []byte(strings.Repeat("k", minimumBypassKeyLength))
+	WithBypassKey(key)(this.verifier)
+	WithAllowedActions("login")(this.verifier)
+	WithRequiredThreshold(0.5)(this.verifier)
+	expires := time.Now().Add(time.Minute)
+
+	lowScore, _ := this.verifier.VerifyContext(context.Background(), NewBypassToken(key, BypassClaims{Expires: expires, Score: BypassScore(0.1)}), "")
+	zeroScore, _ := this.verifier.VerifyContext(context.Background(), NewBypassToken(key, BypassClaims{Expires: expires, Score: BypassScore(0)}), "")
+	wrongAction, _ := this.verifier.VerifyContext(context.Background(), NewBypassToken(key, BypassClaims{Expires: expires, Action: "signup"}), "")
+
+	this.So(lowScore.Accepted, should.BeFalse)
+	this.So(lowScore.Reason, should.Equal, ReasonScoreBelowThreshold)
+	this.So(zeroScore.Accepted, should.BeFalse)
+	this.So(zeroScore.Score, should.Equal, float32(0))
+	this.So(wrongAction.Accepted, should.BeFalse)
+	this.So(wrongAction.Reason, should.Equal, ReasonActionNotAllowed)
+}
+func (this *DefaultVerifierFixture) TestExpiredBypassTokenRejected() {
+	key := []byte(strings.Repeat("k", minimumBypassKeyLength))
+	WithBypassKey(key)(this.verifier)
+	this.verifier.now = func() time.Time { return time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC) }
+
+	result, err := this.verifier.Verify(NewBypassToken(key, BypassClaims{Expires: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}), "")
+
+	this.So(result, should.BeFalse)
+	this.So(err, should.Wrap, ErrInvalidToken)
+	this.So(this.clientCalls, should.Equal, 0)
+}
+
 func (this *DefaultVerifierFixture) TestRequiredThreshold() {
 	this.writeResponseBody(`{}`)
 
@@ -124,6 +360,14 @@ func (this *DefaultVerifierFixture) TestRequiredHostname() {
 	this.So(result, should.BeFalse)
 	this.So(err, should.BeNil)
 }
+func (this *DefaultVerifierFixture) TestRejectionReasonReported() {
+	this.writeResponseBody(`{"Score":1.0}`)
+	WithAllowedHosts("hostname-required")(this.verifier)
+
+	result, _ := this.verifier.VerifyContext(context.Background(), "token", "ip")
+
+	this.So(result.Reason, should.Equal, ReasonHostNotAllowed)
+}
 func (this *DefaultVerifierFixture) TestRequiredAction() {
 	this.writeResponseBody(`{"Score":1.0}`)
 
@@ -137,12 +381,119 @@ func (this *DefaultVerifierFixture) TestRequiredAction() {
 
 /* ------------------------------------------------------------------------------------------------------------------ */
 
+func (this *DefaultVerifierFixture) TestAdaptiveThresholdApplied() {
+	adaptive := NewAdaptiveThreshold(0.3, 0.8, WithMinimumSamples(1))
+	adaptive.Record("login", 0.1, false)
+	WithAdaptiveThreshold(adaptive)(this.verifier)
+	this.writeResponseBody(`{"success":true,"score":0.5,"action":"login"}`)
+
+	result, err := this.verifier.VerifyContext(context.Background(), "token", "ip")
+
+	this.So(err, should.BeNil)
+	this.So(result.Accepted, should.BeFalse)
+	this.So(result.Reason, should.Equal, ReasonScoreBelowThreshold)
+	this.So(adaptive.Snapshot()["login"].Samples, should.Equal, 2)
+}
+
+func (this *DefaultVerifierFixture) TestCachedResultReused() {
+	WithResultCache(NewResultCache(time.Minute, 8))(this.verifier)
+	this.writeResponseBody(`{"success":true,"score":0.9}`)
+
+	first, _ := this.verifier.VerifyContext(context.Background(), "token", "ip")
+	second, err := this.verifier.VerifyContext(context.Background(), "token", "ip")
+
+	this.So(err, should.BeNil)
+	this.So(this.clientCalls, should.Equal, 1)
+	this.So(second, should.Resemble, first)
+}
+func (this *DefaultVerifierFixture) TestLookupErrorsNotCached() {
+	cache := NewResultCache(time.Minute, 8)
+	WithResultCache(cache)(this.verifier)
+	this.writeResponseBody(`{"success":false,"error-codes":["invalid-input-response"]}`)
+
+	_, err := this.verifier.VerifyContext(context.Background(), "token", "ip")
+
+	this.So(err, should.NotBeNil)
+	this.So(cache.Len(), should.Equal, 0)
+}
+
+func (this *DefaultVerifierFixture) TestAndroidPackageDecoded() {
+	WithAllowedHosts("example.com")(this.verifier)
+	WithAllowedAndroidPackages("com.example.app")(this.verifier)
+	this.writeResponseBody(`{"success":true,"score":0.9,"action":"login","apk_package_name":"com.example.app"}`)
+
+	result, err := this.verifier.VerifyContext(context.Background(), "token", "ip")
+
+	this.So(err, should.BeNil)
+	this.So(result.Accepted, should.BeTrue)
+	this.So(result.AndroidPackageName, should.Equal, "com.example.app")
+}
+func (this *DefaultVerifierFixture) TestEnterpriseBundleDecoded() {
+	WithAllowedIOSBundles("com.example.ios")(this.verifier)
+	this.writeResponseBody(`{"success":true,"score":0.9,"tokenProperties":{"iosBundleId":"com.example.other"}}`)
+
+	result, _ := this.verifier.VerifyContext(context.Background(), "token", "ip")
+
+	this.So(result.Accepted, should.BeFalse)
+	this.So(result.IOSBundleID, should.Equal, "com.example.other")
+	this.So(result.Reason, should.Equal, ReasonBundleNotAllowed)
+}
+func (this *DefaultVerifierFixture) TestEnterpriseAssessmentDecoded() {
+	WithAllowedHosts("example.com")(this.verifier)
+	WithAllowedActions("login")(this.verifier)
+	this.writeResponseBody(`{
+		"name": "projects/123/assessments/456",
+		"riskAnalysis": {"score": 0.9, "reasons": []},
+		"tokenProperties": {
+			"valid": true,
+			"invalidReason": "INVALID_REASON_UNSPECIFIED",
+			"hostname": "example.com",
+			"action": "login",
+			"createTime": "2020-01-02T03:04:05.678Z"
+		}
+	}`)
+
+	result, err := this.verifier.VerifyContext(context.Background(), "token", "ip")
+
+	this.So(err, should.BeNil)
+	this.So(result, should.Resemble, Result{
+		Accepted:    true,
+		Score:       0.9,
+		Action:      "login",
+		Hostname:    "example.com",
+		ChallengeTS: time.Date(2020, 1, 2, 3, 4, 5, 678000000, time.UTC),
+	})
+}
+func (this *DefaultVerifierFixture) TestEnterpriseInvalidTokenRejected() {
+	this.writeResponseBody(`{"riskAnalysis":{"score":0},"tokenProperties":{"valid":false,"invalidReason":"DUPE"}}`)
+
+	result, err := this.verifier.VerifyContext(context.Background(), "token", "ip")
+
+	this.So(result.Accepted, should.BeFalse)
+	this.So(err, should.Wrap, ErrInvalidToken)
+	this.So(result.ErrorCodes, should.Resemble, []string{ErrorCodeDuplicate})
+}
+
 func (this *DefaultVerifierFixture) Do(request *http.Request) (*http.Response, error) {
 	_ = request.ParseForm()
 	this.clientCalls++
 	this.clientRequest = request
+	this.clientRequestErr = request.Context().Err()
+	if this.clientDone != nil {
+		defer close(this.clientDone)
+	}
 	return this.clientResponse, this.clientError
 }
 func (this *DefaultVerifierFixture) writeResponseBody(value string) {
 	this.clientResponseBuffer.WriteString(value)
 }
+
+type trackingBody struct {
+	io.Reader
+	closed *bool
+}
+
+func (this *trackingBody) Close() error {
+	*this.closed = true
+	return nil
+}
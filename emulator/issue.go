@@ -0,0 +1,60 @@
+package emulator
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (this *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(SiteverifyPath, this)
+	mux.Handle(IssuePath, http.HandlerFunc(this.serveIssue))
+	return mux
+}
+
+func (this *Server) serveIssue(response http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodPost {
+		http.Error(response, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	} else if token, err := parseToken(request); err != nil {
+		http.Error(response, err.Error(), http.StatusBadRequest)
+	} else {
+		response.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = response.Write([]byte(this.Issue(token) + "\n"))
+	}
+}
+func parseToken(request *http.Request) (token Token, err error) {
+	if err = request.ParseForm(); err != nil {
+		return token, err
+	}
+
+	token.Value = request.Form.Get("token")
+	token.Action = request.Form.Get("action")
+	token.Hostname = request.Form.Get("hostname")
+
+	if value := request.Form.Get("score"); len(value) > 0 {
+		score, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			return token, err
+		}
+		token.Score = float32(score)
+	}
+
+	if value := request.Form.Get("challenge_ts"); len(value) > 0 {
+		if token.ChallengeTS, err = time.Parse(time.RFC3339, value); err != nil {
+			return token, err
+		}
+	}
+
+	if value := request.Form.Get("error-codes"); len(value) > 0 {
+		token.ErrorCodes = strings.Split(value, ",")
+	}
+
+	return token, nil
+}
+
+const (
+	SiteverifyPath = "/recaptcha/api/siteverify"
+	IssuePath      = "/issue"
+)
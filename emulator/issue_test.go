@@ -0,0 +1,85 @@
+package emulator
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+)
+
+func TestIssueFixture(t *testing.T) {
+	gunit.Run(new(IssueFixture), t)
+}
+
+type IssueFixture struct {
+	*gunit.Fixture
+
+	emulator *Server
+	handler  http.Handler
+}
+
+func (this *IssueFixture) Setup() {
+	this.emulator = New()
+	this.handler = this.emulator.Handler()
+}
+
+func (this *IssueFixture) TestTokenIssuedFromForm() {
+	response := this.post(IssuePath, url.Values{
+		"token":        {"my-token"},
+		"score":        {"0.8"},
+		"action":       {"login"},
+		"hostname":     {"example.com"},
+		"challenge_ts": {"2020-01-02T03:04:05Z"},
+		"error-codes":  {"bad-request,invalid-input-secret"},
+	})
+
+	this.So(response.Code, should.Equal, http.StatusOK)
+	this.So(response.Body.String(), should.Equal, "my-token\n")
+	this.So(this.emulator.tokens["my-token"].Token, should.Resemble, Token{
+		Value:       "my-token",
+		Score:       0.8,
+		Action:      "login",
+		Hostname:    "example.com",
+		ChallengeTS: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		ErrorCodes:  []string{ErrorCodeBadRequest, ErrorCodeInvalidSecret},
+	})
+}
+func (this *IssueFixture) TestGeneratedTokenValue() {
+	response := this.post(IssuePath, url.Values{"score": {"0.8"}})
+
+	token := strings.TrimSpace(response.Body.String())
+	this.So(token, should.HaveLength, 48)
+	this.So(this.emulator.tokens, should.ContainKey, token)
+}
+func (this *IssueFixture) TestMalformedScore() {
+	response := this.post(IssuePath, url.Values{"score": {"high"}})
+
+	this.So(response.Code, should.Equal, http.StatusBadRequest)
+	this.So(this.emulator.tokens, should.BeEmpty)
+}
+func (this *IssueFixture) TestIssueRequiresPost() {
+	request := httptest.NewRequest(http.MethodGet, IssuePath, nil)
+	response := httptest.NewRecorder()
+
+	this.handler.ServeHTTP(response, request)
+
+	this.So(response.Code, should.Equal, http.StatusMethodNotAllowed)
+}
+func (this *IssueFixture) TestSiteverifyRouted() {
+	response := this.post(SiteverifyPath, url.Values{"secret": {"any"}, "response": {"unknown"}})
+
+	this.So(response.Body.String(), should.EqualJSON, `{"success":false,"error-codes":["invalid-input-response"]}`)
+}
+
+func (this *IssueFixture) post(path string, values url.Values) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(values.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	response := httptest.NewRecorder()
+	this.handler.ServeHTTP(response, request)
+	return response
+}
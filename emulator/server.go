@@ -0,0 +1,178 @@
+package emulator
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type Server struct {
+	mutex    sync.Mutex
+	tokens   map[string]*issuedToken
+	secret   string
+	lifetime time.Duration
+	latency  time.Duration
+	outage   int
+	now      func() time.Time
+	calls    int
+}
+
+func New(options ...Option) *Server {
+	this := &Server{tokens: make(map[string]*issuedToken)}
+
+	WithSecret("")(this)
+	WithTokenLifetime(defaultTokenLifetime)(this)
+	WithLatency(0)(this)
+	WithClock(time.Now)(this)
+
+	for _, option := range options {
+		option(this)
+	}
+
+	return this
+}
+
+func (this *Server) Issue(token Token) string {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if len(token.Value) == 0 {
+		token.Value = newTokenValue()
+	}
+	if token.ChallengeTS.IsZero() {
+		token.ChallengeTS = this.now()
+	}
+
+	this.tokens[token.Value] = &issuedToken{Token: token}
+	return token.Value
+}
+func (this *Server) Uses(token string) int {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if issued, found := this.tokens[token]; found {
+		return issued.uses
+	}
+
+	return 0
+}
+func (this *Server) Calls() int {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.calls
+}
+func (this *Server) SetLatency(value time.Duration) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.latency = value
+}
+func (this *Server) SetOutage(statusCode int) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.outage = statusCode
+}
+
+func (this *Server) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	latency, outage := this.begin()
+
+	if !this.sleep(request, latency) {
+		return
+	} else if outage > 0 {
+		http.Error(response, http.StatusText(outage), outage)
+	} else if request.Method != http.MethodPost && request.Method != http.MethodGet {
+		http.Error(response, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	} else if err := request.ParseForm(); err != nil {
+		writeJSON(response, newFailure(ErrorCodeBadRequest))
+	} else {
+		writeJSON(response, this.verify(request.Form.Get("secret"), request.Form.Get("response")))
+	}
+}
+func (this *Server) begin() (time.Duration, int) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.calls++
+	return this.latency, this.outage
+}
+func (this *Server) sleep(request *http.Request, latency time.Duration) bool {
+	if latency <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(latency)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-request.Context().Done():
+		return false
+	}
+}
+func (this *Server) verify(secret, token string) siteverifyResponse {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if len(secret) == 0 {
+		return newFailure(ErrorCodeMissingSecret)
+	} else if len(this.secret) > 0 && secret != this.secret {
+		return newFailure(ErrorCodeInvalidSecret)
+	} else if len(token) == 0 {
+		return newFailure(ErrorCodeMissingResponse)
+	}
+
+	issued, found := this.tokens[token]
+	if !found {
+		return newFailure(ErrorCodeInvalidResponse)
+	}
+
+	issued.uses++
+	if issued.uses > 1 || this.now().Sub(issued.ChallengeTS) > this.lifetime {
+		return newFailure(ErrorCodeDuplicate)
+	}
+
+	return issued.response()
+}
+
+func writeJSON(response http.ResponseWriter, body siteverifyResponse) {
+	response.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(response).Encode(body)
+}
+func newTokenValue() string {
+	buffer := make([]byte, 24)
+	_, _ = rand.Read(buffer)
+	return hex.EncodeToString(buffer)
+}
+
+/* ------------------------------------------------------------------------------------------------------------------ */
+
+type Option func(*Server)
+
+func WithSecret(value string) Option {
+	return func(this *Server) { this.secret = value }
+}
+func WithTokenLifetime(value time.Duration) Option {
+	return func(this *Server) { this.lifetime = value }
+}
+func WithLatency(value time.Duration) Option {
+	return func(this *Server) { this.latency = value }
+}
+func WithClock(callback func() time.Time) Option {
+	return func(this *Server) { this.now = callback }
+}
+
+/* ------------------------------------------------------------------------------------------------------------------ */
+
+const defaultTokenLifetime = time.Minute * 2
+
+// Error Code Reference: https://developers.google.com/recaptcha/docs/verify
+const (
+	ErrorCodeMissingSecret   = "missing-input-secret"
+	ErrorCodeInvalidSecret   = "invalid-input-secret"
+	ErrorCodeMissingResponse = "missing-input-response"
+	ErrorCodeInvalidResponse = "invalid-input-response"
+	ErrorCodeBadRequest      = "bad-request"
+	ErrorCodeDuplicate       = "timeout-or-duplicate"
+)
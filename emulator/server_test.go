@@ -0,0 +1,166 @@
+package emulator
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/recaptcha"
+)
+
+func TestServerFixture(t *testing.T) {
+	gunit.Run(new(ServerFixture), t)
+}
+
+type ServerFixture struct {
+	*gunit.Fixture
+
+	now      time.Time
+	emulator *Server
+	server   *httptest.Server
+	verifier *recaptcha.DefaultVerifier
+}
+
+func (this *ServerFixture) Setup() {
+	this.now = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	this.emulator = New(WithSecret("my-secret"), WithClock(func() time.Time { return this.now }))
+	this.server = httptest.NewServer(this.emulator)
+	this.verifier = recaptcha.NewVerifier(
+		recaptcha.WithEndpoint(this.server.URL),
+		recaptcha.WithSecret(func() string { return "my-secret" }),
+		recaptcha.WithRequiredThreshold(0.5),
+		recaptcha.WithAllowedHosts("example.com"),
+		recaptcha.WithAllowedActions("login"))
+}
+func (this *ServerFixture) Teardown() {
+	this.server.Close()
+}
+
+func (this *ServerFixture) TestIssuedTokenAccepted() {
+	token := this.emulator.Issue(Token{Score: 0.9, Action: "login", Hostname: "example.com"})
+
+	result, err := this.verifier.Verify(token, "1.2.3.4")
+
+	this.So(result, should.BeTrue)
+	this.So(err, should.BeNil)
+	this.So(this.emulator.Uses(token), should.Equal, 1)
+	this.So(this.emulator.Calls(), should.Equal, 1)
+}
+func (this *ServerFixture) TestScriptedTokenRejectedByPolicy() {
+	lowScore := this.emulator.Issue(Token{Score: 0.1, Action: "login", Hostname: "example.com"})
+	wrongAction := this.emulator.Issue(Token{Score: 0.9, Action: "signup", Hostname: "example.com"})
+	wrongHost := this.emulator.Issue(Token{Score: 0.9, Action: "login", Hostname: "evil.com"})
+
+	for _, token := range []string{lowScore, wrongAction, wrongHost} {
+		result, err := this.verifier.Verify(token, "")
+
+		this.So(result, should.BeFalse)
+		this.So(err, should.BeNil)
+	}
+}
+func (this *ServerFixture) TestDuplicateUseRejected() {
+	token := this.emulator.Issue(Token{Score: 0.9, Action: "login", Hostname: "example.com"})
+	_, _ = this.verifier.Verify(token, "")
+
+	result, err := this.verifier.Verify(token, "")
+
+	this.So(result, should.BeFalse)
+	this.So(err, should.Wrap, recaptcha.ErrInvalidToken)
+	this.So(this.emulator.Uses(token), should.Equal, 2)
+}
+func (this *ServerFixture) TestExpiredTokenRejected() {
+	token := this.emulator.Issue(Token{Score: 0.9, Action: "login", Hostname: "example.com"})
+	this.now = this.now.Add(defaultTokenLifetime + time.Second)
+
+	result, err := this.verifier.Verify(token, "")
+
+	this.So(result, should.BeFalse)
+	this.So(err, should.Wrap, recaptcha.ErrInvalidToken)
+}
+func (this *ServerFixture) TestUnknownTokenRejected() {
+	result, err := this.verifier.Verify("unknown", "")
+
+	this.So(result, should.BeFalse)
+	this.So(err, should.Wrap, recaptcha.ErrInvalidToken)
+}
+func (this *ServerFixture) TestWrongSecret() {
+	token := this.emulator.Issue(Token{Score: 0.9})
+	recaptcha.WithSecret(func() string { return "wrong" })(this.verifier)
+
+	result, err := this.verifier.Verify(token, "")
+
+	this.So(result, should.BeFalse)
+	this.So(err, should.Wrap, recaptcha.ErrServerConfig)
+}
+func (this *ServerFixture) TestScriptedErrorCodes() {
+	token := this.emulator.Issue(Token{ErrorCodes: []string{ErrorCodeBadRequest}})
+
+	result, err := this.verifier.Verify(token, "")
+
+	this.So(result, should.BeFalse)
+	this.So(err, should.Wrap, recaptcha.ErrServerConfig)
+}
+func (this *ServerFixture) TestOutage() {
+	token := this.emulator.Issue(Token{Score: 0.9, Action: "login", Hostname: "example.com"})
+	this.emulator.SetOutage(http.StatusServiceUnavailable)
+
+	result, err := this.verifier.Verify(token, "")
+
+	this.So(result, should.BeFalse)
+	this.So(err, should.Equal, recaptcha.ErrUpstreamUnavailable)
+	this.So(this.emulator.Uses(token), should.Equal, 0)
+}
+func (this *ServerFixture) TestLatency() {
+	this.emulator.SetLatency(time.Millisecond * 20)
+	started := time.Now()
+
+	_, _ = this.verifier.Verify("token", "")
+
+	this.So(time.Since(started), should.BeGreaterThanOrEqualTo, time.Millisecond*20)
+}
+func (this *ServerFixture) TestLatencyAbandonedWhenClientGivesUp() {
+	this.emulator.SetLatency(time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+	request, _ := http.NewRequestWithContext(ctx, http.MethodPost, this.server.URL, nil)
+
+	_, err := http.DefaultClient.Do(request)
+
+	this.So(err, should.NotBeNil)
+}
+func (this *ServerFixture) TestResponseFormat() {
+	token := this.emulator.Issue(Token{Score: 0.7, Action: "login", Hostname: "example.com"})
+
+	response, _ := http.PostForm(this.server.URL, url.Values{"secret": {"my-secret"}, "response": {token}})
+	body := readBody(response)
+
+	this.So(response.Header.Get("Content-Type"), should.StartWith, "application/json")
+	this.So(body, should.EqualJSON, `{
+		"success": true,
+		"score": 0.7,
+		"action": "login",
+		"hostname": "example.com",
+		"challenge_ts": "2020-01-02T03:04:05Z"
+	}`)
+}
+func (this *ServerFixture) TestMissingInputs() {
+	response, _ := http.PostForm(this.server.URL, url.Values{})
+
+	this.So(readBody(response), should.EqualJSON, `{"success":false,"error-codes":["missing-input-secret"]}`)
+
+	response, _ = http.PostForm(this.server.URL, url.Values{"secret": {"my-secret"}})
+
+	this.So(readBody(response), should.EqualJSON, `{"success":false,"error-codes":["missing-input-response"]}`)
+}
+
+func readBody(response *http.Response) string {
+	defer func() { _ = response.Body.Close() }()
+	body, _ := io.ReadAll(response.Body)
+	return string(body)
+}
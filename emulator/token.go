@@ -0,0 +1,44 @@
+package emulator
+
+import "time"
+
+type Token struct {
+	Value       string
+	Score       float32
+	Action      string
+	Hostname    string
+	ChallengeTS time.Time
+	ErrorCodes  []string
+}
+
+type issuedToken struct {
+	Token
+	uses int
+}
+
+func (this *issuedToken) response() siteverifyResponse {
+	if len(this.ErrorCodes) > 0 {
+		return newFailure(this.ErrorCodes...)
+	}
+
+	return siteverifyResponse{
+		Success:     true,
+		Score:       this.Score,
+		Action:      this.Action,
+		Hostname:    this.Hostname,
+		ChallengeTS: this.ChallengeTS.UTC().Format(time.RFC3339),
+	}
+}
+
+type siteverifyResponse struct {
+	Success     bool     `json:"success"`
+	Score       float32  `json:"score,omitempty"`
+	Action      string   `json:"action,omitempty"`
+	Hostname    string   `json:"hostname,omitempty"`
+	ChallengeTS string   `json:"challenge_ts,omitempty"`
+	ErrorCodes  []string `json:"error-codes,omitempty"`
+}
+
+func newFailure(codes ...string) siteverifyResponse {
+	return siteverifyResponse{ErrorCodes: codes}
+}
@@ -0,0 +1,71 @@
+package recaptcha
+
+import "expvar"
+
+type ExpvarObserver struct {
+	verifications *expvar.Int
+	latency       *expvar.Float
+	outcomes      *expvar.Map
+	scores        *expvar.Map
+	actions       *expvar.Map
+	hostnames     *expvar.Map
+	errors        *expvar.Map
+	reportOnly    *expvar.Map
+	disagreements *expvar.Map
+	labels        *observerLabels
+}
+
+func NewExpvarObserver(name string, options ...ObserverOption) *ExpvarObserver {
+	this := &ExpvarObserver{
+		verifications: new(expvar.Int),
+		latency:       new(expvar.Float),
+		outcomes:      new(expvar.Map).Init(),
+		scores:        new(expvar.Map).Init(),
+		actions:       new(expvar.Map).Init(),
+		hostnames:     new(expvar.Map).Init(),
+		errors:        new(expvar.Map).Init(),
+		reportOnly:    new(expvar.Map).Init(),
+		disagreements: new(expvar.Map).Init(),
+		labels:        newObserverLabels(options),
+	}
+
+	root := expvar.NewMap(name)
+	root.Set("verifications", this.verifications)
+	root.Set("latency_seconds_total", this.latency)
+	root.Set("outcomes", this.outcomes)
+	root.Set("scores", this.scores)
+	root.Set("actions", this.actions)
+	root.Set("hostnames", this.hostnames)
+	root.Set("errors", this.errors)
+	root.Set("report_only", this.reportOnly)
+	root.Set("disagreements", this.disagreements)
+	return this
+}
+
+func (this *ExpvarObserver) Observe(decision Decision) {
+	this.verifications.Add(1)
+	this.outcomes.Add(string(decision.Outcome), 1)
+
+	if !decision.Enforced {
+		this.reportOnly.Add(string(decision.Outcome), 1)
+	}
+	if decision.Disagrees() {
+		this.disagreements.Add(string(decision.Outcome)+"/"+string(decision.Candidate), 1)
+	}
+
+	if decision.consulted() {
+		this.latency.Add(decision.Duration.Seconds())
+	}
+	if decision.answered() {
+		this.scores.Add(decision.Result.ScoreBucket(), 1)
+	}
+	if code := decision.ErrorCode(); len(code) > 0 {
+		this.errors.Add(code, 1)
+	}
+	if len(decision.Result.Action) > 0 {
+		this.actions.Add(this.labels.actions.fold(decision.Result.Action), 1)
+	}
+	if len(decision.Result.Hostname) > 0 {
+		this.hostnames.Add(this.labels.hostnames.fold(decision.Result.Hostname), 1)
+	}
+}
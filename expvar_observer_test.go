@@ -0,0 +1,61 @@
+package recaptcha
+
+import (
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+)
+
+func TestExpvarObserverFixture(t *testing.T) {
+	gunit.Run(new(ExpvarObserverFixture), t)
+}
+
+type ExpvarObserverFixture struct {
+	*gunit.Fixture
+}
+
+func (this *ExpvarObserverFixture) TestDecisionsCounted() {
+	observer := NewExpvarObserver("recaptcha-" + this.Name())
+
+	observer.Observe(Decision{
+		Outcome:  OutcomeAccepted,
+		Enforced: true,
+		Result:   Result{Accepted: true, Score: 0.9, Action: "login", Hostname: "example.com"},
+		Duration: time.Millisecond * 250,
+	})
+	observer.Observe(Decision{
+		Outcome:  OutcomeRejected,
+		Enforced: true,
+		Error:    &VerificationError{Codes: []string{ErrorCodeDuplicate}},
+	})
+	observer.Observe(Decision{Outcome: OutcomeFailOpen, Error: ErrUpstreamUnavailable, Candidate: OutcomeFailOpen})
+	observer.Observe(Decision{Outcome: OutcomeRejected, Result: Result{Score: 0.1}, Candidate: OutcomeAccepted})
+	observer.Observe(Decision{Outcome: OutcomeAllowlisted, Enforced: true, Result: Result{Reason: ReasonClientAllowlisted}, Duration: time.Second})
+	observer.Observe(Decision{Outcome: OutcomeRejected, Enforced: true, Result: Result{Reason: ReasonMissingToken}, Duration: time.Second})
+
+	published := expvar.Get("recaptcha-" + this.Name())
+	this.So(published.String(), should.EqualJSON, `{
+		"verifications": 6,
+		"latency_seconds_total": 0.25,
+		"outcomes": {"accepted": 1, "rejected": 3, "fail-open": 1, "allowlisted": 1},
+		"report_only": {"rejected": 1, "fail-open": 1},
+		"disagreements": {"rejected/accepted": 1},
+		"scores": {"0.1": 1, "0.9": 1},
+		"actions": {"login": 1},
+		"hostnames": {"example.com": 1},
+		"errors": {"timeout-or-duplicate": 1, "upstream-unavailable": 1}
+	}`)
+}
+func (this *ExpvarObserverFixture) TestUnlistedLabelsFoldedIntoOther() {
+	observer := NewExpvarObserver("recaptcha-"+this.Name(), WithObservedActions("login"), WithObservedHostnames("example.com"))
+
+	observer.Observe(Decision{Outcome: OutcomeRejected, Result: Result{Action: "login", Hostname: "example.com"}})
+	observer.Observe(Decision{Outcome: OutcomeRejected, Result: Result{Action: "random", Hostname: "attacker.net"}})
+
+	published := expvar.Get("recaptcha-" + this.Name()).(*expvar.Map)
+	this.So(published.Get("actions").String(), should.EqualJSON, `{"login": 1, "other": 1}`)
+	this.So(published.Get("hostnames").String(), should.EqualJSON, `{"example.com": 1, "other": 1}`)
+}
@@ -0,0 +1,71 @@
+package recaptcha
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type flightGroup struct {
+	mutex   sync.Mutex
+	flights map[string]*flight
+	timeout time.Duration
+}
+
+func newFlightGroup(timeout time.Duration) *flightGroup {
+	return &flightGroup{flights: make(map[string]*flight), timeout: timeout}
+}
+
+func (this *flightGroup) Do(ctx context.Context, key string, call func(context.Context) (Result, error)) (Result, error) {
+	this.mutex.Lock()
+	if existing, found := this.flights[key]; found {
+		existing.waiters++
+		this.mutex.Unlock()
+		return existing.wait(ctx)
+	}
+
+	current := &flight{done: make(chan struct{})}
+	this.flights[key] = current
+	this.mutex.Unlock()
+
+	detached, cancel := context.WithTimeout(context.WithoutCancel(ctx), this.timeout)
+	go func() {
+		defer cancel()
+		defer this.land(key, current)
+		current.result, current.err = call(detached)
+	}()
+
+	return current.wait(ctx)
+}
+func (this *flightGroup) land(key string, current *flight) {
+	this.mutex.Lock()
+	delete(this.flights, key)
+	this.mutex.Unlock()
+	close(current.done)
+}
+
+func (this *flightGroup) waiters(key string) int {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if current, found := this.flights[key]; found {
+		return current.waiters
+	}
+	return 0
+}
+
+type flight struct {
+	done    chan struct{}
+	waiters int
+	result  Result
+	err     error
+}
+
+func (this *flight) wait(ctx context.Context) (Result, error) {
+	select {
+	case <-this.done:
+		return this.result, this.err
+	case <-ctx.Done():
+		return Result{}, ctx.Err()
+	}
+}
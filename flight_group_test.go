@@ -0,0 +1,218 @@
+package recaptcha
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+)
+
+func TestFlightGroupFixture(t *testing.T) {
+	gunit.Run(new(FlightGroupFixture), t)
+}
+
+type FlightGroupFixture struct {
+	*gunit.Fixture
+
+	group   *flightGroup
+	calls   int32
+	release chan struct{}
+}
+
+func (this *FlightGroupFixture) Setup() {
+	this.group = newFlightGroup(time.Minute)
+	this.release = make(chan struct{})
+}
+
+func (this *FlightGroupFixture) TestConcurrentCallsShareOneResult() {
+	results := this.launch(context.Background(), "token", 8)
+	this.await(func() bool { return this.group.waiters("token") == 7 })
+	close(this.release)
+
+	for result := range results {
+		this.So(result, should.Resemble, Result{Accepted: true, Score: 0.9})
+	}
+	this.So(atomic.LoadInt32(&this.calls), should.Equal, 1)
+}
+func (this *FlightGroupFixture) TestDistinctKeysNotShared() {
+	close(this.release)
+
+	first := this.launch(context.Background(), "a", 1)
+	second := this.launch(context.Background(), "b", 1)
+	<-first
+	<-second
+
+	this.So(atomic.LoadInt32(&this.calls), should.Equal, 2)
+}
+func (this *FlightGroupFixture) TestSequentialCallsNotShared() {
+	close(this.release)
+
+	<-this.launch(context.Background(), "token", 1)
+	<-this.launch(context.Background(), "token", 1)
+
+	this.So(atomic.LoadInt32(&this.calls), should.Equal, 2)
+}
+func (this *FlightGroupFixture) TestWaiterGivesUpWithContextError() {
+	leader := this.launch(context.Background(), "token", 1)
+	this.await(func() bool { return atomic.LoadInt32(&this.calls) == 1 })
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := this.group.Do(ctx, "token", this.call)
+
+	this.So(result, should.Resemble, Result{})
+	this.So(err, should.Equal, context.Canceled)
+
+	close(this.release)
+	<-leader
+}
+func (this *FlightGroupFixture) TestCanceledLeaderDoesNotFailFollowers() {
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := make(chan error, 1)
+	go func() {
+		_, err := this.group.Do(ctx, "token", this.call)
+		leader <- err
+	}()
+	this.await(func() bool { return atomic.LoadInt32(&this.calls) == 1 })
+	followers := this.launch(context.Background(), "token", 2)
+	this.await(func() bool { return this.group.waiters("token") == 2 })
+
+	cancel()
+	this.So(<-leader, should.Equal, context.Canceled)
+	close(this.release)
+
+	for result := range followers {
+		this.So(result, should.Resemble, Result{Accepted: true, Score: 0.9})
+	}
+}
+func (this *FlightGroupFixture) TestSharedCallDetachedFromLeaderCancellation() {
+	var detached error
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	done := make(chan struct{})
+
+	_, _ = this.group.Do(ctx, "token", func(ctx context.Context) (Result, error) {
+		defer close(done)
+		detached = ctx.Err()
+		return Result{}, nil
+	})
+	<-done
+
+	this.So(detached, should.BeNil)
+}
+func (this *FlightGroupFixture) TestVerifierCoalescesConcurrentLookups() {
+	var hits int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		<-release
+		response.Header().Set(contentTypeHeader, jsonContentType)
+		_, _ = response.Write([]byte(`{"success":true,"score":0.9}`))
+	}))
+	defer server.Close()
+
+	verifier := NewVerifier(WithEndpoint(server.URL))
+	accepted := make(chan bool, 4)
+	for i := 0; i < cap(accepted); i++ {
+		go func() {
+			result, _ := verifier.Verify("token", "ip")
+			accepted <- result
+		}()
+	}
+
+	this.await(func() bool { return verifier.flights.waiters(verifier.flightKey("token", "ip")) == cap(accepted)-1 })
+	close(release)
+
+	for i := 0; i < cap(accepted); i++ {
+		this.So(<-accepted, should.BeTrue)
+	}
+	this.So(atomic.LoadInt32(&hits), should.Equal, 1)
+}
+func (this *FlightGroupFixture) TestSharedLookupTracedWithCallerCount() {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, _ *http.Request) {
+		<-release
+		response.Header().Set(contentTypeHeader, jsonContentType)
+		_, _ = response.Write([]byte(`{"success":true,"score":0.9}`))
+	}))
+	defer server.Close()
+
+	tracer := new(recordingTracer)
+	verifier := NewVerifier(WithEndpoint(server.URL), WithVerifierTracer(tracer))
+	done := make(chan struct{}, 3)
+	for i := 0; i < cap(done); i++ {
+		go func() {
+			_, _ = verifier.Verify("token", "ip")
+			done <- struct{}{}
+		}()
+	}
+
+	this.await(func() bool { return verifier.flights.waiters(verifier.flightKey("token", "ip")) == cap(done)-1 })
+	close(release)
+	for i := 0; i < cap(done); i++ {
+		<-done
+	}
+
+	this.So(tracer.spans, should.HaveLength, 1)
+	this.So(tracer.spans[0].attributes[AttributeAttempts], should.Equal, cap(done))
+}
+func (this *FlightGroupFixture) TestVerifierKeysFlightsByClient() {
+	uncached := NewVerifier()
+	cached := NewVerifier(WithResultCache(NewResultCache(time.Minute, 8, WithSameClientIP(false))))
+
+	this.So(uncached.flightKey("token", "a"), should.NotEqual, uncached.flightKey("token", "b"))
+	this.So(cached.flightKey("token", "a"), should.NotEqual, cached.flightKey("token", "b"))
+}
+
+func (this *FlightGroupFixture) TestDetachedCallBoundedByTimeout() {
+	group := newFlightGroup(time.Millisecond * 10)
+
+	_, err := group.Do(context.Background(), "token", func(ctx context.Context) (Result, error) {
+		<-ctx.Done()
+		return Result{}, ctx.Err()
+	})
+
+	this.So(err, should.Wrap, context.DeadlineExceeded)
+	this.So(group.waiters("token"), should.Equal, 0)
+}
+
+func (this *FlightGroupFixture) launch(ctx context.Context, key string, count int) chan Result {
+	results := make(chan Result, count)
+	waiter := new(sync.WaitGroup)
+	waiter.Add(count)
+
+	for i := 0; i < count; i++ {
+		go func() {
+			defer waiter.Done()
+			result, _ := this.group.Do(ctx, key, this.call)
+			results <- result
+		}()
+	}
+
+	go func() { waiter.Wait(); close(results) }()
+	return results
+}
+func (this *FlightGroupFixture) call(context.Context) (Result, error) {
+	atomic.AddInt32(&this.calls, 1)
+	<-this.release
+	return Result{Accepted: true, Score: 0.9}, nil
+}
+func (this *FlightGroupFixture) await(condition func() bool) {
+	deadline := time.Now().Add(awaitTimeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			this.Error("Timed out waiting for the expected concurrent state.")
+			return
+		}
+		runtime.Gosched()
+	}
+}
+
+const awaitTimeout = time.Millisecond * 500
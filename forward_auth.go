@@ -0,0 +1,107 @@
+package recaptcha
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+func forwardAuthAccepted(response http.ResponseWriter, _ *http.Request) {
+	response.WriteHeader(http.StatusOK)
+}
+
+func forwardAuthToken(request *http.Request, fallback func(*http.Request) string) string {
+	if token := request.Header.Get(TokenHeader); len(token) > 0 {
+		return token
+	}
+
+	for _, name := range originalURIHeaders {
+		if original, err := url.ParseRequestURI(request.Header.Get(name)); err == nil {
+			if token := original.Query().Get(DefaultFormTokenName); len(token) > 0 {
+				return token
+			}
+		}
+	}
+
+	return fallback(request)
+}
+func forwardAuthClientIP(request *http.Request, trustedProxies networkList, fallback func(*http.Request) string) string {
+	if trustedProxies.contains(parseClientIP(request.RemoteAddr)) {
+		if address := parseClientIP(request.Header.Get(realIPHeader)); address != nil {
+			return address.String()
+		}
+		if address := nearestUntrustedHop(request.Header.Values(forwardedForHeader), trustedProxies); address != nil {
+			return address.String()
+		}
+	}
+
+	address := fallback(request)
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		return host
+	}
+	return address
+}
+func TrustedProxyClientIPReader(header string, trustedProxies ...string) func(*http.Request) string {
+	networks := parseNetworks(trustedProxies)
+	return func(request *http.Request) string {
+		peer := parseClientIP(request.RemoteAddr)
+		if peer == nil {
+			return request.RemoteAddr
+		} else if !networks.contains(peer) {
+			return peer.String()
+		} else if address := nearestUntrustedHop(request.Header.Values(header), networks); address != nil {
+			return address.String()
+		} else {
+			return peer.String()
+		}
+	}
+}
+func nearestUntrustedHop(headers []string, trustedProxies networkList) net.IP {
+	hops := strings.Split(strings.Join(headers, ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		if address := parseClientIP(hops[i]); address == nil {
+			return nil
+		} else if !trustedProxies.contains(address) {
+			return address
+		}
+	}
+
+	return nil
+}
+
+func writeDecisionHeaders(headers http.Header, decision Decision) {
+	headers.Set(OutcomeHeader, string(decision.Outcome))
+	if reason := decision.Reason(); len(reason) > 0 {
+		headers.Set(ReasonHeader, reason)
+	}
+
+	if decision.Error != nil || (decision.Outcome != OutcomeAccepted && decision.Outcome != OutcomeRejected) {
+		return
+	}
+
+	headers.Set(ScoreHeader, strconv.FormatFloat(decision.Result.score(), 'g', -1, 64))
+	if len(decision.Result.Action) > 0 {
+		headers.Set(ActionHeader, decision.Result.Action)
+	}
+	if len(decision.Result.Hostname) > 0 {
+		headers.Set(HostnameHeader, decision.Result.Hostname)
+	}
+}
+
+/* ------------------------------------------------------------------------------------------------------------------ */
+
+const (
+	TokenHeader    = "X-Recaptcha-Token"
+	OutcomeHeader  = "X-Recaptcha-Outcome"
+	ReasonHeader   = "X-Recaptcha-Reason"
+	ScoreHeader    = "X-Recaptcha-Score"
+	ActionHeader   = "X-Recaptcha-Action"
+	HostnameHeader = "X-Recaptcha-Hostname"
+
+	realIPHeader       = "X-Real-IP"
+	forwardedForHeader = "X-Forwarded-For"
+)
+
+var originalURIHeaders = []string{"X-Original-URI", "X-Forwarded-Uri"}
@@ -0,0 +1,228 @@
+package recaptcha
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+)
+
+func TestForwardAuthFixture(t *testing.T) {
+	gunit.Run(new(ForwardAuthFixture), t)
+}
+
+type ForwardAuthFixture struct {
+	*gunit.Fixture
+
+	handler  *DefaultHandler
+	request  *http.Request
+	response *httptest.ResponseRecorder
+
+	token    string
+	clientIP string
+	result   Result
+	err      error
+}
+
+func (this *ForwardAuthFixture) Setup() {
+	this.handler = NewHandler(this, WithForwardAuth("10.0.0.0/8"))
+	this.request = httptest.NewRequest(http.MethodGet, "/auth", nil)
+	this.request.RemoteAddr = "10.0.0.1:1234"
+	this.response = httptest.NewRecorder()
+	this.result = Result{Accepted: true, Score: 0.9, Action: "login", Hostname: "example.com"}
+}
+
+func (this *ForwardAuthFixture) TestAcceptedAnsweredWithDecisionHeaders() {
+	this.request.Header.Set(TokenHeader, "token")
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.So(this.response.Code, should.Equal, http.StatusOK)
+	this.So(this.response.Body.Len(), should.Equal, 0)
+	this.So(this.token, should.Equal, "token")
+	this.So(this.response.Header().Get(OutcomeHeader), should.Equal, "accepted")
+	this.So(this.response.Header().Get(ScoreHeader), should.Equal, "0.9")
+	this.So(this.response.Header().Get(ActionHeader), should.Equal, "login")
+	this.So(this.response.Header().Get(HostnameHeader), should.Equal, "example.com")
+}
+func (this *ForwardAuthFixture) TestRejectedAnsweredWithReason() {
+	this.result = Result{Score: 0.1, Action: "login", Reason: ReasonScoreBelowThreshold}
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.So(this.response.Code, should.Equal, http.StatusForbidden)
+	this.So(this.response.Header().Get(OutcomeHeader), should.Equal, "rejected")
+	this.So(this.response.Header().Get(ReasonHeader), should.Equal, ReasonScoreBelowThreshold)
+	this.So(this.response.Header().Get(ScoreHeader), should.Equal, "0.1")
+}
+func (this *ForwardAuthFixture) TestErrorsOmitScoreHeaders() {
+	this.result, this.err = Result{}, ErrServerConfig
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.So(this.response.Code, should.Equal, http.StatusForbidden)
+	this.So(this.response.Header().Get(OutcomeHeader), should.Equal, "error")
+	this.So(this.response.Header().Get(ReasonHeader), should.Equal, "server-config")
+	this.So(this.response.Header().Get(ScoreHeader), should.BeEmpty)
+}
+func (this *ForwardAuthFixture) TestThrottledAnsweredAsRejected() {
+	WithRateLimiter(&rateLimiterFake{retryAfter: time.Second})(this.handler)
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.So(this.response.Code, should.Equal, http.StatusForbidden)
+	this.So(this.response.Header().Get(OutcomeHeader), should.Equal, "throttled")
+	this.So(this.response.Header().Get(ReasonHeader), should.Equal, ReasonRateLimited)
+	this.So(this.response.Header().Get(retryAfterHeader), should.Equal, "1")
+}
+func (this *ForwardAuthFixture) TestAlternateRejectedStatusUsedForEveryDenial() {
+	WithRejectedStatus(http.StatusUnauthorized)(this.handler)
+	this.result, this.err = Result{}, ErrServerConfig
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.So(this.response.Code, should.Equal, http.StatusUnauthorized)
+}
+func (this *ForwardAuthFixture) TestExactScoreReported() {
+	this.result.Score = 0.87
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.So(this.response.Header().Get(ScoreHeader), should.Equal, "0.87")
+}
+func (this *ForwardAuthFixture) TestTokenReadFromOriginalURI() {
+	this.request.Header.Set("X-Original-URI", "/signup?"+DefaultFormTokenName+"=nginx-token")
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.So(this.token, should.Equal, "nginx-token")
+}
+func (this *ForwardAuthFixture) TestTokenReadFromForwardedURI() {
+	this.request.Header.Set("X-Forwarded-Uri", "/signup?"+DefaultFormTokenName+"=traefik-token")
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.So(this.token, should.Equal, "traefik-token")
+}
+func (this *ForwardAuthFixture) TestClientIPFromRealIP() {
+	this.request.Header.Set("X-Real-IP", "1.2.3.4")
+	this.request.Header.Set("X-Forwarded-For", "5.6.7.8")
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.So(this.clientIP, should.Equal, "1.2.3.4")
+}
+func (this *ForwardAuthFixture) TestClientIPFromNearestForwardedFor() {
+	this.request.Header.Set("X-Forwarded-For", "9.9.9.9, 5.6.7.8")
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.So(this.clientIP, should.Equal, "5.6.7.8")
+}
+func (this *ForwardAuthFixture) TestClientIPSkipsTrustedForwardedHops() {
+	this.request.Header.Add("X-Forwarded-For", "9.9.9.9, 5.6.7.8")
+	this.request.Header.Add("X-Forwarded-For", "10.0.0.7")
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.So(this.clientIP, should.Equal, "5.6.7.8")
+}
+func (this *ForwardAuthFixture) TestForwardedHeadersIgnoredFromUntrustedPeer() {
+	this.request.RemoteAddr = "203.0.113.9:1234"
+	this.request.Header.Set("X-Real-IP", "1.2.3.4")
+	this.request.Header.Set("X-Forwarded-For", "5.6.7.8")
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.So(this.clientIP, should.Equal, "203.0.113.9")
+}
+func (this *ForwardAuthFixture) TestForwardedHeadersIgnoredWithoutTrustedProxies() {
+	this.handler = NewHandler(this, WithForwardAuth())
+	this.request.Header.Set("X-Real-IP", "1.2.3.4")
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.So(this.clientIP, should.Equal, "10.0.0.1")
+}
+func (this *ForwardAuthFixture) TestEarlierReaderOptionsKeptAsFallbacks() {
+	this.handler = NewHandler(this,
+		WithTokenReader(HeaderTokenReader("X-Custom-Token")),
+		WithClientIPReader(func(*http.Request) string { return "192.0.2.1" }),
+		WithForwardAuth("10.0.0.0/8"))
+	this.request.Header.Set("X-Custom-Token", "custom-token")
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.So(this.token, should.Equal, "custom-token")
+	this.So(this.clientIP, should.Equal, "192.0.2.1")
+}
+func (this *ForwardAuthFixture) TestInnerHandlerNotCalled() {
+	inner := &recordingInnerHandler{}
+	this.handler = NewHandler(this, WithForwardAuth("10.0.0.0/8"), WithInnerHandler(inner))
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.So(this.response.Code, should.Equal, http.StatusOK)
+	this.So(inner.calls, should.Equal, 0)
+}
+func (this *ForwardAuthFixture) TestBadTrustedProxyPanics() {
+	this.So(func() { WithForwardAuth("not-a-network") }, should.Panic)
+}
+func (this *ForwardAuthFixture) TestClientIPFromPeerAddress() {
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.So(this.clientIP, should.Equal, "10.0.0.1")
+}
+func (this *ForwardAuthFixture) TestReportOnlyAlwaysAllows() {
+	WithReportOnly(true)(this.handler)
+	this.result = Result{Score: 0.1}
+
+	this.handler.ServeHTTP(this.response, this.request)
+
+	this.So(this.response.Code, should.Equal, http.StatusOK)
+	this.So(this.response.Header().Get(OutcomeHeader), should.Equal, "rejected")
+}
+
+func (this *ForwardAuthFixture) TestTrustedProxyReaderUsesNearestUntrustedHop() {
+	reader := TrustedProxyClientIPReader("X-Client-IP", "10.0.0.0/8")
+	this.request.Header.Add("X-Client-IP", "1.2.3.4, 5.6.7.8")
+	this.request.Header.Add("X-Client-IP", "10.0.0.2")
+
+	this.So(reader(this.request), should.Equal, "5.6.7.8")
+}
+func (this *ForwardAuthFixture) TestTrustedProxyReaderIgnoresHeaderFromUntrustedPeer() {
+	reader := TrustedProxyClientIPReader("X-Client-IP", "10.0.0.0/8")
+	this.request.RemoteAddr = "192.0.2.1:1234"
+	this.request.Header.Set("X-Client-IP", "1.2.3.4")
+
+	this.So(reader(this.request), should.Equal, "192.0.2.1")
+}
+func (this *ForwardAuthFixture) TestTrustedProxyReaderFallsBackToPeer() {
+	reader := TrustedProxyClientIPReader("X-Client-IP", "10.0.0.0/8")
+
+	this.So(reader(this.request), should.Equal, "10.0.0.1")
+
+	this.request.Header.Set("X-Client-IP", "garbage")
+	this.So(reader(this.request), should.Equal, "10.0.0.1")
+}
+func (this *ForwardAuthFixture) TestTrustedProxyReaderRejectsBadProxies() {
+	this.So(func() { TrustedProxyClientIPReader("X-Client-IP", "not-a-network") }, should.Panic)
+}
+
+type recordingInnerHandler struct{ calls int }
+
+func (this *recordingInnerHandler) ServeHTTP(http.ResponseWriter, *http.Request) { this.calls++ }
+
+/* ------------------------------------------------------------------------------------------------------------------ */
+
+func (this *ForwardAuthFixture) Verify(string, string) (bool, error) {
+	panic("VerifyContext should be preferred")
+}
+func (this *ForwardAuthFixture) VerifyContext(_ context.Context, token, clientIP string) (Result, error) {
+	this.token, this.clientIP = token, clientIP
+	return this.result, this.err
+}
@@ -1,8 +1,11 @@
 module github.com/smartystreets/recaptcha
 
-go 1.13
+go 1.21
 
 require (
 	github.com/smartystreets/assertions v1.2.0
 	github.com/smartystreets/gunit v1.4.2
+	golang.org/x/net v0.35.0
 )
+
+require golang.org/x/text v0.22.0 // indirect
@@ -0,0 +1,83 @@
+package recaptcha
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+type hostPatterns struct {
+	wildcards   []string
+	suffixes    []string
+	expressions []*regexp.Regexp
+}
+
+func parseHosts(values []string) (exact map[string]struct{}, patterns hostPatterns) {
+	exact = make(map[string]struct{}, len(values))
+
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		switch {
+		case strings.HasPrefix(value, regexpHostPrefix):
+			patterns.expressions = append(patterns.expressions, compileHostExpression(value))
+		case strings.HasPrefix(value, wildcardHostPrefix):
+			patterns.wildcards = append(patterns.wildcards, "."+normalizeHost(value[len(wildcardHostPrefix):]))
+		case strings.HasPrefix(value, "."):
+			patterns.suffixes = append(patterns.suffixes, "."+normalizeHost(value[1:]))
+		default:
+			exact[value] = struct{}{}
+			exact[normalizeHost(value)] = struct{}{}
+		}
+	}
+
+	return exact, patterns
+}
+func compileHostExpression(value string) *regexp.Regexp {
+	expression, err := regexp.Compile("^(?:" + value[len(regexpHostPrefix):] + ")$")
+	if err != nil {
+		panic(fmt.Errorf("%w: %q is not a valid host expression: %s", errBadOptionProvided, value, err))
+	}
+
+	return expression
+}
+
+func (this hostPatterns) empty() bool {
+	return len(this.wildcards) == 0 && len(this.suffixes) == 0 && len(this.expressions) == 0
+}
+func (this hostPatterns) matches(hostname string) bool {
+	for _, wildcard := range this.wildcards {
+		if label := strings.TrimSuffix(hostname, wildcard); label != hostname && len(label) > 0 && !strings.Contains(label, ".") {
+			return true
+		}
+	}
+
+	for _, suffix := range this.suffixes {
+		if len(hostname) > len(suffix) && strings.HasSuffix(hostname, suffix) {
+			return true
+		}
+	}
+
+	for _, expression := range this.expressions {
+		if expression.MatchString(hostname) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func normalizeHost(value string) string {
+	value = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(value)), ".")
+	if ascii, err := idna.Lookup.ToASCII(value); err == nil {
+		return ascii
+	}
+
+	return value
+}
+
+const (
+	wildcardHostPrefix = "*."
+	regexpHostPrefix   = "regexp:"
+)
@@ -0,0 +1,78 @@
+package recaptcha
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+)
+
+func TestHostPatternsFixture(t *testing.T) {
+	gunit.Run(new(HostPatternsFixture), t)
+}
+
+type HostPatternsFixture struct {
+	*gunit.Fixture
+}
+
+func (this *HostPatternsFixture) TestExactHostsNormalized() {
+	origins := this.origins("Example.COM.", "bücher.example")
+
+	this.So(origins.permitsHost("example.com"), should.BeTrue)
+	this.So(origins.permitsHost("EXAMPLE.com."), should.BeTrue)
+	this.So(origins.permitsHost("xn--bcher-kva.example"), should.BeTrue)
+	this.So(origins.permitsHost("BÜCHER.example"), should.BeTrue)
+	this.So(origins.permitsHost("other.com"), should.BeFalse)
+}
+func (this *HostPatternsFixture) TestWildcardMatchesSingleLabel() {
+	origins := this.origins("*.shop.example.com")
+
+	this.So(origins.permitsHost("tenant.shop.example.com"), should.BeTrue)
+	this.So(origins.permitsHost("Tenant.Shop.Example.com."), should.BeTrue)
+	this.So(origins.permitsHost("a.tenant.shop.example.com"), should.BeFalse)
+	this.So(origins.permitsHost("shop.example.com"), should.BeFalse)
+	this.So(origins.permitsHost("evilshop.example.com"), should.BeFalse)
+}
+func (this *HostPatternsFixture) TestSuffixMatchesAnyDepth() {
+	origins := this.origins(".example.com")
+
+	this.So(origins.permitsHost("a.example.com"), should.BeTrue)
+	this.So(origins.permitsHost("a.b.example.com"), should.BeTrue)
+	this.So(origins.permitsHost("example.com"), should.BeFalse)
+	this.So(origins.permitsHost("badexample.com"), should.BeFalse)
+}
+func (this *HostPatternsFixture) TestRegularExpression() {
+	origins := this.origins(`regexp:^tenant-[0-9]+\.example\.com$`)
+
+	this.So(origins.permitsHost("tenant-42.example.com"), should.BeTrue)
+	this.So(origins.permitsHost("TENANT-42.example.com."), should.BeTrue)
+	this.So(origins.permitsHost("tenant-x.example.com"), should.BeFalse)
+}
+func (this *HostPatternsFixture) TestRegularExpressionAlwaysAnchored() {
+	origins := this.origins(`regexp:tenant-[0-9]+\.example\.com`, `regexp:a|b\.example\.com`)
+
+	this.So(origins.permitsHost("tenant-1.example.com"), should.BeTrue)
+	this.So(origins.permitsHost("tenant-1.example.com.attacker.net"), should.BeFalse)
+	this.So(origins.permitsHost("evil-tenant-1.example.com"), should.BeFalse)
+	this.So(origins.permitsHost("b.example.com"), should.BeTrue)
+	this.So(origins.permitsHost("a.attacker.net"), should.BeFalse)
+}
+func (this *HostPatternsFixture) TestInvalidExpressionPanics() {
+	defer func() {
+		this.So(errors.Is(recover().(error), errBadOptionProvided), should.BeTrue)
+	}()
+
+	WithAllowedHosts("regexp:(")
+}
+func (this *HostPatternsFixture) TestPatternsApplyThroughVerifierPolicy() {
+	policy := NewPolicy(WithAllowedHosts("*.shop.example.com"), WithRequiredThreshold(0))
+
+	this.So(policy.Accepts(Result{Hostname: "tenant.shop.example.com"}), should.BeTrue)
+	this.So(policy.Accepts(Result{Hostname: "example.com"}), should.BeFalse)
+}
+
+func (this *HostPatternsFixture) origins(values ...string) allowedOrigins {
+	hosts, patterns := parseHosts(values)
+	return allowedOrigins{hosts: hosts, hostPatterns: patterns}
+}
@@ -1,12 +1,32 @@
 package recaptcha
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"fmt"
+)
 
 type TokenVerifier interface {
 	Verify(token, ipAddress string) (bool, error)
 }
 
+type ContextVerifier interface {
+	TokenVerifier
+	VerifyContext(ctx context.Context, token, ipAddress string) (Result, error)
+}
+
+type Observer interface {
+	Observe(Decision)
+}
+
 var (
 	ErrLookupFailure = errors.New("unable to look up the status of the token provided")
 	ErrServerConfig  = errors.New("the token response has one or more configuration-related errors")
+	ErrInvalidToken  = errors.New("the token provided is missing, malformed, expired, or has already been used")
+
+	ErrUpstreamUnavailable = fmt.Errorf("%w: the verification endpoint responded with a server error", ErrLookupFailure)
+	ErrMalformedResponse   = fmt.Errorf("%w: the verification endpoint response could not be understood", ErrLookupFailure)
+	ErrUpstreamRejected    = fmt.Errorf("%w: the verification endpoint rejected the request", ErrServerConfig)
+
+	ErrTokenTransport = errors.New("unable to attach the token to the outbound request")
 )
@@ -0,0 +1,50 @@
+package recaptcha
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+type networkList []*net.IPNet
+
+func parseNetworks(values []string) (networks networkList) {
+	for _, value := range values {
+		networks = append(networks, parseNetwork(strings.TrimSpace(value)))
+	}
+
+	return networks
+}
+func parseNetwork(value string) *net.IPNet {
+	if _, network, err := net.ParseCIDR(value); err == nil {
+		return network
+	} else if address := net.ParseIP(value); address == nil {
+		panic(fmt.Errorf("%w: %q is not an IP address or CIDR range", errBadOptionProvided, value))
+	} else if ipv4 := address.To4(); ipv4 != nil {
+		return &net.IPNet{IP: ipv4, Mask: net.CIDRMask(32, 32)}
+	} else {
+		return &net.IPNet{IP: address, Mask: net.CIDRMask(128, 128)}
+	}
+}
+
+func (this networkList) contains(address net.IP) bool {
+	if address == nil {
+		return false
+	}
+
+	for _, network := range this {
+		if network.Contains(address) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseClientIP(value string) net.IP {
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		value = host
+	}
+
+	return net.ParseIP(strings.TrimSpace(value))
+}
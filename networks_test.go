@@ -0,0 +1,49 @@
+package recaptcha
+
+import (
+	"net"
+	"testing"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+)
+
+func TestNetworksFixture(t *testing.T) {
+	gunit.Run(new(NetworksFixture), t)
+}
+
+type NetworksFixture struct {
+	*gunit.Fixture
+}
+
+func (this *NetworksFixture) TestCIDRRanges() {
+	networks := parseNetworks([]string{"10.0.0.0/8", " 2001:db8::/32 "})
+
+	this.So(networks.contains(net.ParseIP("10.1.2.3")), should.BeTrue)
+	this.So(networks.contains(net.ParseIP("11.1.2.3")), should.BeFalse)
+	this.So(networks.contains(net.ParseIP("2001:db8::1")), should.BeTrue)
+	this.So(networks.contains(net.ParseIP("2001:db9::1")), should.BeFalse)
+}
+func (this *NetworksFixture) TestSingleAddresses() {
+	networks := parseNetworks([]string{"192.0.2.1", "2001:db8::1"})
+
+	this.So(networks.contains(net.ParseIP("192.0.2.1")), should.BeTrue)
+	this.So(networks.contains(net.ParseIP("192.0.2.2")), should.BeFalse)
+	this.So(networks.contains(net.ParseIP("::ffff:192.0.2.1")), should.BeTrue)
+	this.So(networks.contains(net.ParseIP("2001:db8::1")), should.BeTrue)
+	this.So(networks.contains(net.ParseIP("2001:db8::2")), should.BeFalse)
+}
+func (this *NetworksFixture) TestEmptyListContainsNothing() {
+	this.So(parseNetworks(nil).contains(net.ParseIP("192.0.2.1")), should.BeFalse)
+	this.So(parseNetworks([]string{"0.0.0.0/0"}).contains(nil), should.BeFalse)
+}
+func (this *NetworksFixture) TestMalformedNetworkPanics() {
+	this.So(func() { parseNetworks([]string{"not-a-network"}) }, should.Panic)
+}
+func (this *NetworksFixture) TestParseClientIP() {
+	this.So(parseClientIP("192.0.2.1").String(), should.Equal, "192.0.2.1")
+	this.So(parseClientIP("192.0.2.1:1234").String(), should.Equal, "192.0.2.1")
+	this.So(parseClientIP("[2001:db8::1]:443").String(), should.Equal, "2001:db8::1")
+	this.So(parseClientIP("2001:db8::1").String(), should.Equal, "2001:db8::1")
+	this.So(parseClientIP("unknown"), should.BeNil)
+}
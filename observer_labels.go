@@ -0,0 +1,88 @@
+package recaptcha
+
+import "sync"
+
+type labelSet struct {
+	mutex   sync.Mutex
+	allowed func(string) bool
+	seen    map[string]struct{}
+	limit   int
+}
+
+func newLabelSet(limit int) *labelSet {
+	return &labelSet{seen: make(map[string]struct{}), limit: limit}
+}
+
+func labelAllowlist(values []string) func(string) bool {
+	if len(values) == 0 {
+		return nil
+	}
+
+	allowed := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		allowed[value] = struct{}{}
+	}
+
+	return func(value string) bool { _, found := allowed[value]; return found }
+}
+
+func (this *labelSet) fold(value string) string {
+	if len(value) == 0 {
+		return value
+	} else if this.allowed != nil && this.allowed(value) {
+		return value
+	} else if this.allowed != nil {
+		return OtherLabel
+	}
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if _, found := this.seen[value]; found {
+		return value
+	} else if len(this.seen) >= this.limit {
+		return OtherLabel
+	}
+
+	this.seen[value] = struct{}{}
+	return value
+}
+
+/* ------------------------------------------------------------------------------------------------------------------ */
+
+type observerLabels struct {
+	actions   *labelSet
+	hostnames *labelSet
+}
+
+func newObserverLabels(options []ObserverOption) *observerLabels {
+	this := &observerLabels{actions: newLabelSet(defaultLabelLimit), hostnames: newLabelSet(defaultLabelLimit)}
+
+	for _, option := range options {
+		option(this)
+	}
+
+	return this
+}
+
+type ObserverOption func(*observerLabels)
+
+func WithObservedActions(values ...string) ObserverOption {
+	allowed := labelAllowlist(values)
+	return func(this *observerLabels) { this.actions.allowed = allowed }
+}
+func WithObservedHostnames(values ...string) ObserverOption {
+	hosts, patterns := parseHosts(values)
+	origins := allowedOrigins{hosts: hosts, hostPatterns: patterns}
+	return func(this *observerLabels) {
+		this.hostnames.allowed = nil
+		if len(values) > 0 {
+			this.hostnames.allowed = origins.permitsHost
+		}
+	}
+}
+
+const (
+	OtherLabel        = "other"
+	defaultLabelLimit = 32
+)
@@ -0,0 +1,9 @@
+package recaptcha
+
+type observers []Observer
+
+func (this observers) Observe(decision Decision) {
+	for _, observer := range this {
+		observer.Observe(decision)
+	}
+}
@@ -0,0 +1,27 @@
+package recaptcha
+
+type Policy interface {
+	Accepts(Result) bool
+}
+
+func NewPolicy(options ...VerifierOption) Policy {
+	return verifierPolicy{verifier: NewVerifier(options...)}
+}
+
+type verifierPolicy struct {
+	verifier *DefaultVerifier
+}
+
+func (this verifierPolicy) Accepts(result Result) bool {
+	lookup := defaultLookup{
+		Score:           result.Score,
+		Action:          result.Action,
+		Hostname:        result.Hostname,
+		ApkPackageName:  result.AndroidPackageName,
+		TokenProperties: enterpriseTokenProperties{IOSBundleID: result.IOSBundleID},
+		Errors:          result.ErrorCodes,
+	}
+
+	accepted, _ := lookup.IsValid(this.verifier.origins, this.verifier.actions, this.verifier.threshold)
+	return accepted
+}
@@ -0,0 +1,39 @@
+package recaptcha
+
+import (
+	"testing"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+)
+
+func TestPolicyFixture(t *testing.T) {
+	gunit.Run(new(PolicyFixture), t)
+}
+
+type PolicyFixture struct {
+	*gunit.Fixture
+}
+
+func (this *PolicyFixture) TestDefaultPolicyUsesDefaultThreshold() {
+	policy := NewPolicy()
+
+	this.So(policy.Accepts(Result{Score: defaultThreshold}), should.BeTrue)
+	this.So(policy.Accepts(Result{Score: defaultThreshold - 0.1}), should.BeFalse)
+}
+func (this *PolicyFixture) TestPolicyFromVerifierOptions() {
+	policy := NewPolicy(
+		WithRequiredThreshold(0.7),
+		WithAllowedHosts("example.com"),
+		WithAllowedActions("login"))
+
+	this.So(policy.Accepts(Result{Score: 0.7, Hostname: "example.com", Action: "login"}), should.BeTrue)
+	this.So(policy.Accepts(Result{Score: 0.6, Hostname: "example.com", Action: "login"}), should.BeFalse)
+	this.So(policy.Accepts(Result{Score: 0.7, Hostname: "evil.com", Action: "login"}), should.BeFalse)
+	this.So(policy.Accepts(Result{Score: 0.7, Hostname: "example.com", Action: "signup"}), should.BeFalse)
+}
+func (this *PolicyFixture) TestErrorCodesNeverAccepted() {
+	policy := NewPolicy(WithRequiredThreshold(0))
+
+	this.So(policy.Accepts(Result{Score: 1, ErrorCodes: []string{ErrorCodeDuplicate}}), should.BeFalse)
+}
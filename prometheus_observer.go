@@ -0,0 +1,184 @@
+package recaptcha
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type PrometheusObserver struct {
+	mutex         sync.Mutex
+	verifications map[verificationLabels]uint64
+	errors        map[string]uint64
+	disagreements map[[2]Outcome]uint64
+	latency       *histogram
+	scores        *histogram
+	labels        *observerLabels
+}
+
+func NewPrometheusObserver(options ...ObserverOption) *PrometheusObserver {
+	return &PrometheusObserver{
+		verifications: make(map[verificationLabels]uint64),
+		errors:        make(map[string]uint64),
+		disagreements: make(map[[2]Outcome]uint64),
+		latency:       newHistogram(latencyBuckets),
+		scores:        newHistogram(scoreHistogramBuckets),
+		labels:        newObserverLabels(options),
+	}
+}
+
+func (this *PrometheusObserver) Observe(decision Decision) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.verifications[verificationLabels{
+		outcome:  string(decision.Outcome),
+		enforced: decision.Enforced,
+		action:   this.labels.actions.fold(decision.Result.Action),
+		hostname: this.labels.hostnames.fold(decision.Result.Hostname),
+	}]++
+
+	if decision.consulted() {
+		this.latency.observe(decision.Duration.Seconds())
+	}
+	if decision.answered() {
+		this.scores.observe(decision.Result.score())
+	}
+	if code := decision.ErrorCode(); len(code) > 0 {
+		this.errors[code]++
+	}
+	if decision.Disagrees() {
+		this.disagreements[[2]Outcome{decision.Outcome, decision.Candidate}]++
+	}
+}
+
+func (this *PrometheusObserver) ServeHTTP(response http.ResponseWriter, _ *http.Request) {
+	response.Header().Set(contentTypeHeader, prometheusContentType)
+	this.Export(response)
+}
+func (this *PrometheusObserver) Export(writer io.Writer) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	writeHeader(writer, "recaptcha_verifications_total", "counter", "Verification decisions by outcome, enforcement, action, and hostname.")
+	for _, labels := range this.sortedVerificationLabels() {
+		_, _ = fmt.Fprintf(writer, "recaptcha_verifications_total{outcome=%s,enforced=\"%t\",action=%s,hostname=%s} %d\n",
+			quoteLabel(labels.outcome), labels.enforced, quoteLabel(labels.action), quoteLabel(labels.hostname), this.verifications[labels])
+	}
+
+	writeHeader(writer, "recaptcha_policy_disagreements_total", "counter", "Decisions where the candidate policy disagreed with the enforced outcome.")
+	for _, key := range this.sortedDisagreements() {
+		_, _ = fmt.Fprintf(writer, "recaptcha_policy_disagreements_total{outcome=%s,candidate=%s} %d\n",
+			quoteLabel(string(key[0])), quoteLabel(string(key[1])), this.disagreements[key])
+	}
+
+	writeHeader(writer, "recaptcha_verification_errors_total", "counter", "Verification errors by error code.")
+	for _, code := range sortedKeys(this.errors) {
+		_, _ = fmt.Fprintf(writer, "recaptcha_verification_errors_total{code=%s} %d\n", quoteLabel(code), this.errors[code])
+	}
+
+	writeHeader(writer, "recaptcha_verification_duration_seconds", "histogram", "Time spent deciding whether to accept a request.")
+	this.latency.writeTo(writer, "recaptcha_verification_duration_seconds")
+
+	writeHeader(writer, "recaptcha_score", "histogram", "Distribution of scores reported by the verification endpoint.")
+	this.scores.writeTo(writer, "recaptcha_score")
+}
+func (this *PrometheusObserver) sortedVerificationLabels() (keys []verificationLabels) {
+	for key := range this.verifications {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].less(keys[j]) })
+	return keys
+}
+
+func (this *PrometheusObserver) sortedDisagreements() (keys [][2]Outcome) {
+	for key := range this.disagreements {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i][0] < keys[j][0] || (keys[i][0] == keys[j][0] && keys[i][1] < keys[j][1])
+	})
+	return keys
+}
+
+func writeHeader(writer io.Writer, name, kind, help string) {
+	_, _ = fmt.Fprintf(writer, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+func quoteLabel(value string) string {
+	return `"` + labelEscaper.Replace(value) + `"`
+}
+func sortedKeys(values map[string]uint64) (keys []string) {
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+/* ------------------------------------------------------------------------------------------------------------------ */
+
+type verificationLabels struct {
+	outcome  string
+	enforced bool
+	action   string
+	hostname string
+}
+
+func (this verificationLabels) less(that verificationLabels) bool {
+	if this.outcome != that.outcome {
+		return this.outcome < that.outcome
+	} else if this.enforced != that.enforced {
+		return that.enforced
+	} else if this.action != that.action {
+		return this.action < that.action
+	} else {
+		return this.hostname < that.hostname
+	}
+}
+
+/* ------------------------------------------------------------------------------------------------------------------ */
+
+type histogram struct {
+	bounds []float64
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]uint64, len(bounds))}
+}
+
+func (this *histogram) observe(value float64) {
+	for i, bound := range this.bounds {
+		if value <= bound {
+			this.counts[i]++
+		}
+	}
+	this.sum += value
+	this.count++
+}
+func (this *histogram) writeTo(writer io.Writer, name string) {
+	for i, bound := range this.bounds {
+		_, _ = fmt.Fprintf(writer, "%s_bucket{le=\"%s\"} %d\n", name, formatFloat(bound), this.counts[i])
+	}
+	_, _ = fmt.Fprintf(writer, "%s_bucket{le=\"+Inf\"} %d\n", name, this.count)
+	_, _ = fmt.Fprintf(writer, "%s_sum %s\n", name, formatFloat(this.sum))
+	_, _ = fmt.Fprintf(writer, "%s_count %d\n", name, this.count)
+}
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+var (
+	latencyBuckets        = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+	scoreHistogramBuckets = []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9, 1}
+)
+
+const prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
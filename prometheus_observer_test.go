@@ -0,0 +1,131 @@
+package recaptcha
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+)
+
+func TestPrometheusObserverFixture(t *testing.T) {
+	gunit.Run(new(PrometheusObserverFixture), t)
+}
+
+type PrometheusObserverFixture struct {
+	*gunit.Fixture
+
+	observer *PrometheusObserver
+}
+
+func (this *PrometheusObserverFixture) Setup() {
+	this.observer = NewPrometheusObserver()
+}
+
+func (this *PrometheusObserverFixture) TestEmptyExposition() {
+	body := this.scrape()
+
+	this.So(body, should.ContainSubstring, "# TYPE recaptcha_verifications_total counter\n")
+	this.So(body, should.ContainSubstring, "# TYPE recaptcha_verification_errors_total counter\n")
+	this.So(body, should.ContainSubstring, "# TYPE recaptcha_score histogram\n")
+	this.So(body, should.ContainSubstring, "recaptcha_score_bucket{le=\"+Inf\"} 0\n")
+	this.So(body, should.ContainSubstring, "recaptcha_verification_duration_seconds_count 0\n")
+}
+func (this *PrometheusObserverFixture) TestDecisionsExposed() {
+	this.observer.Observe(Decision{
+		Outcome:  OutcomeAccepted,
+		Enforced: true,
+		Result:   Result{Accepted: true, Score: 0.7, Action: "login", Hostname: "example.com"},
+		Duration: time.Millisecond * 20,
+	})
+	this.observer.Observe(Decision{
+		Outcome:  OutcomeAccepted,
+		Enforced: true,
+		Result:   Result{Accepted: true, Score: 0.9, Action: "login", Hostname: "example.com"},
+		Duration: time.Millisecond * 40,
+	})
+	this.observer.Observe(Decision{Outcome: OutcomeFailOpen, Error: ErrUpstreamUnavailable, Duration: time.Second * 3})
+
+	body := this.scrape()
+
+	this.So(body, should.ContainSubstring, "recaptcha_verifications_total{outcome=\"accepted\",enforced=\"true\",action=\"login\",hostname=\"example.com\"} 2\n")
+	this.So(body, should.ContainSubstring, "recaptcha_verifications_total{outcome=\"fail-open\",enforced=\"false\",action=\"\",hostname=\"\"} 1\n")
+	this.So(body, should.ContainSubstring, "recaptcha_verification_errors_total{code=\"upstream-unavailable\"} 1\n")
+	this.So(body, should.ContainSubstring, "recaptcha_score_bucket{le=\"0.6\"} 0\n")
+	this.So(body, should.ContainSubstring, "recaptcha_score_bucket{le=\"0.7\"} 1\n")
+	this.So(body, should.ContainSubstring, "recaptcha_score_bucket{le=\"0.9\"} 2\n")
+	this.So(body, should.ContainSubstring, "recaptcha_score_count 2\n")
+	this.So(body, should.ContainSubstring, "recaptcha_verification_duration_seconds_bucket{le=\"0.025\"} 1\n")
+	this.So(body, should.ContainSubstring, "recaptcha_verification_duration_seconds_bucket{le=\"0.05\"} 2\n")
+	this.So(body, should.ContainSubstring, "recaptcha_verification_duration_seconds_bucket{le=\"5\"} 3\n")
+	this.So(body, should.ContainSubstring, "recaptcha_verification_duration_seconds_count 3\n")
+}
+func (this *PrometheusObserverFixture) TestUnansweredDecisionsSkipScoreAndLatency() {
+	this.observer.Observe(Decision{Outcome: OutcomeAllowlisted, Result: Result{Reason: ReasonClientAllowlisted}, Duration: time.Second})
+	this.observer.Observe(Decision{Outcome: OutcomeDenylisted, Result: Result{Reason: ReasonClientDenylisted}, Duration: time.Second})
+	this.observer.Observe(Decision{Outcome: OutcomeThrottled, Result: Result{Reason: ReasonRateLimited}, Duration: time.Second})
+	this.observer.Observe(Decision{Outcome: OutcomeRejected, Result: Result{Reason: ReasonMissingToken}, Duration: time.Second})
+	this.observer.Observe(Decision{Outcome: OutcomeAccepted, Result: Result{Accepted: true, Score: 1, Bypass: true}, Duration: time.Second})
+	this.observer.Observe(Decision{Outcome: OutcomeRejected, Error: ErrInvalidToken, Duration: time.Second})
+
+	body := this.scrape()
+
+	this.So(body, should.ContainSubstring, "recaptcha_score_count 0\n")
+	this.So(body, should.ContainSubstring, "recaptcha_verification_duration_seconds_count 1\n")
+}
+func (this *PrometheusObserverFixture) TestDisagreementsExposed() {
+	this.observer.Observe(Decision{Outcome: OutcomeAccepted, Candidate: OutcomeRejected})
+	this.observer.Observe(Decision{Outcome: OutcomeAccepted, Candidate: OutcomeRejected})
+	this.observer.Observe(Decision{Outcome: OutcomeRejected, Candidate: OutcomeRejected})
+
+	body := this.scrape()
+
+	this.So(body, should.ContainSubstring, "# TYPE recaptcha_policy_disagreements_total counter\n")
+	this.So(body, should.ContainSubstring, "recaptcha_policy_disagreements_total{outcome=\"accepted\",candidate=\"rejected\"} 2\n")
+	this.So(body, should.NotContainSubstring, "recaptcha_policy_disagreements_total{outcome=\"rejected\"")
+}
+func (this *PrometheusObserverFixture) TestUnlistedLabelsFoldedIntoOther() {
+	this.observer = NewPrometheusObserver(WithObservedActions("login"), WithObservedHostnames("*.example.com"))
+	this.observer.Observe(Decision{Outcome: OutcomeRejected, Result: Result{Action: "login", Hostname: "www.example.com"}})
+	this.observer.Observe(Decision{Outcome: OutcomeRejected, Result: Result{Action: "random-1", Hostname: "attacker.net"}})
+	this.observer.Observe(Decision{Outcome: OutcomeRejected, Result: Result{Action: "random-2", Hostname: "evil.net"}})
+
+	body := this.scrape()
+
+	this.So(body, should.ContainSubstring, "recaptcha_verifications_total{outcome=\"rejected\",enforced=\"false\",action=\"login\",hostname=\"www.example.com\"} 1\n")
+	this.So(body, should.ContainSubstring, "recaptcha_verifications_total{outcome=\"rejected\",enforced=\"false\",action=\"other\",hostname=\"other\"} 2\n")
+	this.So(body, should.NotContainSubstring, "random")
+}
+func (this *PrometheusObserverFixture) TestLabelsBoundedWithoutAllowlist() {
+	this.observer = NewPrometheusObserver(WithObservedActions(), WithObservedHostnames())
+	for i := 0; i < defaultLabelLimit+10; i++ {
+		this.observer.Observe(Decision{Outcome: OutcomeRejected, Result: Result{Action: fmt.Sprintf("action-%d", i)}})
+	}
+
+	body := this.scrape()
+
+	this.So(body, should.ContainSubstring, "action=\"action-0\"")
+	this.So(body, should.NotContainSubstring, fmt.Sprintf("action=\"action-%d\"", defaultLabelLimit))
+	this.So(body, should.ContainSubstring, "recaptcha_verifications_total{outcome=\"rejected\",enforced=\"false\",action=\"other\",hostname=\"\"} 10\n")
+}
+func (this *PrometheusObserverFixture) TestLabelValuesEscaped() {
+	this.observer.Observe(Decision{Outcome: OutcomeRejected, Result: Result{Action: "a\"b\\c\nd"}})
+
+	this.So(this.scrape(), should.ContainSubstring, `action="a\"b\\c\nd"`)
+}
+func (this *PrometheusObserverFixture) TestContentType() {
+	response := httptest.NewRecorder()
+
+	this.observer.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	this.So(response.Header().Get(contentTypeHeader), should.Equal, prometheusContentType)
+}
+
+func (this *PrometheusObserverFixture) scrape() string {
+	response := httptest.NewRecorder()
+	this.observer.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	return response.Body.String()
+}
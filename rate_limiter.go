@@ -0,0 +1,130 @@
+package recaptcha
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+type RateLimiter interface {
+	Reserve(key string, cost float64) (retryAfter time.Duration, allowed bool)
+	Charge(key string, cost float64)
+}
+
+type BucketStore interface {
+	Reserve(key string, rate, burst, cost float64, now time.Time) (retryAfter time.Duration, allowed bool)
+	Charge(key string, rate, burst, cost float64, now time.Time)
+}
+
+/* ------------------------------------------------------------------------------------------------------------------ */
+
+type TokenBucketLimiter struct {
+	store BucketStore
+	rate  float64
+	burst float64
+	now   func() time.Time
+}
+
+func NewTokenBucketLimiter(ratePerSecond, burst float64, options ...LimiterOption) *TokenBucketLimiter {
+	if ratePerSecond <= 0 || burst <= 0 {
+		panic(fmt.Errorf("%w: rate (%v) and burst (%v) must be positive", errBadOptionProvided, ratePerSecond, burst))
+	}
+
+	this := &TokenBucketLimiter{rate: ratePerSecond, burst: burst, now: time.Now}
+
+	WithBucketStore(NewMemoryBucketStore(durationOf(burst*2, ratePerSecond)))(this)
+
+	for _, option := range options {
+		option(this)
+	}
+
+	return this
+}
+
+func (this *TokenBucketLimiter) Reserve(key string, cost float64) (retryAfter time.Duration, allowed bool) {
+	return this.store.Reserve(key, this.rate, this.burst, cost, this.now())
+}
+func (this *TokenBucketLimiter) Charge(key string, cost float64) {
+	this.store.Charge(key, this.rate, this.burst, cost, this.now())
+}
+
+type LimiterOption func(*TokenBucketLimiter)
+
+func WithBucketStore(value BucketStore) LimiterOption {
+	return func(this *TokenBucketLimiter) { this.store = value }
+}
+
+/* ------------------------------------------------------------------------------------------------------------------ */
+
+type MemoryBucketStore struct {
+	mutex   sync.Mutex
+	buckets map[string]bucket
+	idle    time.Duration
+	pruned  time.Time
+}
+
+func NewMemoryBucketStore(idle time.Duration) *MemoryBucketStore {
+	return &MemoryBucketStore{buckets: make(map[string]bucket), idle: idle}
+}
+
+func (this *MemoryBucketStore) Reserve(key string, rate, burst, cost float64, now time.Time) (retryAfter time.Duration, allowed bool) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	current := this.refill(key, rate, burst, now)
+	if allowed = current.tokens >= cost; allowed {
+		current.tokens -= cost
+	} else {
+		retryAfter = durationOf(cost-current.tokens, rate)
+	}
+
+	this.buckets[key] = current
+	return retryAfter, allowed
+}
+func (this *MemoryBucketStore) Charge(key string, rate, burst, cost float64, now time.Time) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	current := this.refill(key, rate, burst, now)
+	current.tokens = math.Max(current.tokens-cost, -burst)
+	this.buckets[key] = current
+}
+func (this *MemoryBucketStore) refill(key string, rate, burst float64, now time.Time) bucket {
+	this.prune(now)
+
+	current, found := this.buckets[key]
+	if !found {
+		return bucket{tokens: burst, updated: now}
+	}
+
+	elapsed := math.Max(0, now.Sub(current.updated).Seconds())
+	return bucket{tokens: math.Min(burst, current.tokens+elapsed*rate), updated: now}
+}
+func (this *MemoryBucketStore) prune(now time.Time) {
+	if now.Sub(this.pruned) < this.idle {
+		return
+	}
+
+	for key, current := range this.buckets {
+		if now.Sub(current.updated) >= this.idle {
+			delete(this.buckets, key)
+		}
+	}
+
+	this.pruned = now
+}
+func (this *MemoryBucketStore) Len() int {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return len(this.buckets)
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func durationOf(tokens, rate float64) time.Duration {
+	return time.Duration(tokens / rate * float64(time.Second))
+}
@@ -0,0 +1,138 @@
+package recaptcha
+
+import (
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+)
+
+func TestRateLimiterFixture(t *testing.T) {
+	gunit.Run(new(RateLimiterFixture), t)
+}
+
+type RateLimiterFixture struct {
+	*gunit.Fixture
+
+	now     time.Time
+	limiter *TokenBucketLimiter
+}
+
+func (this *RateLimiterFixture) Setup() {
+	this.now = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	this.limiter = NewTokenBucketLimiter(1, 3)
+	this.limiter.now = func() time.Time { return this.now }
+}
+
+func (this *RateLimiterFixture) TestBurstAllowed() {
+	for i := 0; i < 3; i++ {
+		_, allowed := this.limiter.Reserve("key", 1)
+		this.So(allowed, should.BeTrue)
+	}
+
+	retryAfter, allowed := this.limiter.Reserve("key", 1)
+
+	this.So(allowed, should.BeFalse)
+	this.So(retryAfter, should.Equal, time.Second)
+}
+func (this *RateLimiterFixture) TestTokensRefillOverTime() {
+	_, _ = this.limiter.Reserve("key", 3)
+
+	this.now = this.now.Add(time.Millisecond * 1500)
+	_, first := this.limiter.Reserve("key", 1)
+	retryAfter, second := this.limiter.Reserve("key", 1)
+
+	this.So(first, should.BeTrue)
+	this.So(second, should.BeFalse)
+	this.So(retryAfter, should.Equal, time.Millisecond*500)
+}
+func (this *RateLimiterFixture) TestRefillCappedAtBurst() {
+	this.now = this.now.Add(time.Hour)
+	_, _ = this.limiter.Reserve("key", 1)
+
+	this.now = this.now.Add(time.Hour)
+	_, allowed := this.limiter.Reserve("key", 4)
+
+	this.So(allowed, should.BeFalse)
+}
+func (this *RateLimiterFixture) TestKeysIndependent() {
+	_, _ = this.limiter.Reserve("a", 3)
+
+	_, allowed := this.limiter.Reserve("b", 3)
+
+	this.So(allowed, should.BeTrue)
+}
+func (this *RateLimiterFixture) TestChargeCanExhaustBudget() {
+	this.limiter.Charge("key", 10)
+
+	retryAfter, allowed := this.limiter.Reserve("key", 1)
+
+	this.So(allowed, should.BeFalse)
+	this.So(retryAfter, should.Equal, time.Second*4) // the debt is capped at one full burst
+}
+func (this *RateLimiterFixture) TestPluggableStore() {
+	store := &recordingBucketStore{retryAfter: time.Second}
+	limiter := NewTokenBucketLimiter(1, 3, WithBucketStore(store))
+	limiter.now = func() time.Time { return this.now }
+
+	retryAfter, allowed := limiter.Reserve("key", 1)
+	limiter.Charge("key", 4)
+
+	this.So(allowed, should.BeFalse)
+	this.So(retryAfter, should.Equal, time.Second)
+	this.So(store.calls, should.Resemble, []bucketStoreCall{
+		{method: "Reserve", key: "key", rate: 1, burst: 3, cost: 1, now: this.now},
+		{method: "Charge", key: "key", rate: 1, burst: 3, cost: 4, now: this.now},
+	})
+}
+func (this *RateLimiterFixture) TestNonPositiveRateOrBurstPanics() {
+	this.So(func() { NewTokenBucketLimiter(0, 3) }, should.Panic)
+	this.So(func() { NewTokenBucketLimiter(-1, 3) }, should.Panic)
+	this.So(func() { NewTokenBucketLimiter(1, 0) }, should.Panic)
+}
+
+func (this *RateLimiterFixture) TestMemoryStorePrunesIdleBuckets() {
+	store := NewMemoryBucketStore(time.Minute)
+	store.Charge("stale", 1, 3, 1, this.now)
+
+	this.now = this.now.Add(time.Minute)
+	store.Charge("fresh", 1, 3, 1, this.now)
+
+	this.So(store.Len(), should.Equal, 1)
+}
+func (this *RateLimiterFixture) TestMemoryStoreKeepsBucketsBetweenCalls() {
+	store := NewMemoryBucketStore(time.Minute)
+
+	_, first := store.Reserve("key", 1, 1, 1, this.now)
+	retryAfter, second := store.Reserve("key", 1, 1, 1, this.now)
+
+	this.So(first, should.BeTrue)
+	this.So(second, should.BeFalse)
+	this.So(retryAfter, should.Equal, time.Second)
+}
+
+/* ------------------------------------------------------------------------------------------------------------------ */
+
+type recordingBucketStore struct {
+	calls      []bucketStoreCall
+	retryAfter time.Duration
+	allowed    bool
+}
+
+type bucketStoreCall struct {
+	method string
+	key    string
+	rate   float64
+	burst  float64
+	cost   float64
+	now    time.Time
+}
+
+func (this *recordingBucketStore) Reserve(key string, rate, burst, cost float64, now time.Time) (time.Duration, bool) {
+	this.calls = append(this.calls, bucketStoreCall{method: "Reserve", key: key, rate: rate, burst: burst, cost: cost, now: now})
+	return this.retryAfter, this.allowed
+}
+func (this *recordingBucketStore) Charge(key string, rate, burst, cost float64, now time.Time) {
+	this.calls = append(this.calls, bucketStoreCall{method: "Charge", key: key, rate: rate, burst: burst, cost: cost, now: now})
+}
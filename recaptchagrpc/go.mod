@@ -0,0 +1,18 @@
+module github.com/smartystreets/recaptcha/recaptchagrpc
+
+go 1.21
+
+require (
+	github.com/smartystreets/assertions v1.2.0
+	github.com/smartystreets/gunit v1.4.2
+	github.com/smartystreets/recaptcha v0.0.0-20261019154726-ada3658aca9c
+	google.golang.org/grpc v1.67.1
+)
+
+require (
+	golang.org/x/net v0.35.0 // indirect
+	golang.org/x/sys v0.30.0 // indirect
+	golang.org/x/text v0.22.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240814211410-ddb44dafa142 // indirect
+	google.golang.org/protobuf v1.34.2 // indirect
+)
@@ -0,0 +1,146 @@
+package recaptchagrpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/smartystreets/recaptcha"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type Interceptor struct {
+	verifier  recaptcha.TokenVerifier
+	tokenKey  string
+	clientIP  func(context.Context) string
+	protected []string
+	skipped   []string
+}
+
+func New(verifier recaptcha.TokenVerifier, options ...Option) *Interceptor {
+	this := &Interceptor{verifier: verifier}
+
+	WithTokenMetadataKey(DefaultTokenMetadataKey)(this)
+	WithClientIPReader(defaultClientIPReader)(this)
+
+	for _, option := range options {
+		option(this)
+	}
+
+	return this
+}
+
+func (this *Interceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := this.authorize(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, request)
+	}
+}
+func (this *Interceptor) Stream() grpc.StreamServerInterceptor {
+	return func(server interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := this.authorize(stream.Context(), info.FullMethod); err != nil {
+			return err
+		}
+
+		return handler(server, stream)
+	}
+}
+
+func (this *Interceptor) authorize(ctx context.Context, method string) error {
+	if !this.protects(method) {
+		return nil
+	}
+
+	accepted, err := this.verify(ctx, this.token(ctx), this.clientIP(ctx))
+	if err == nil && accepted {
+		return nil
+	} else if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	} else if err == nil || errors.Is(err, recaptcha.ErrInvalidToken) {
+		return status.Error(codes.PermissionDenied, rejectedMessage)
+	} else if errors.Is(err, recaptcha.ErrLookupFailure) {
+		return status.Error(codes.Unavailable, unavailableMessage)
+	} else {
+		return status.Error(codes.Internal, internalMessage)
+	}
+}
+func (this *Interceptor) verify(ctx context.Context, token, clientIP string) (bool, error) {
+	if verifier, ok := this.verifier.(recaptcha.ContextVerifier); ok {
+		result, err := verifier.VerifyContext(ctx, token, clientIP)
+		return result.Accepted, err
+	}
+
+	return this.verifier.Verify(token, clientIP)
+}
+func (this *Interceptor) token(ctx context.Context) string {
+	if values := metadata.ValueFromIncomingContext(ctx, this.tokenKey); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+func (this *Interceptor) protects(method string) bool {
+	if matchesMethod(this.skipped, method) {
+		return false
+	}
+
+	return len(this.protected) == 0 || matchesMethod(this.protected, method)
+}
+
+func matchesMethod(rules []string, method string) bool {
+	for _, rule := range rules {
+		if rule == method || (strings.HasSuffix(rule, "/") && strings.HasPrefix(method, rule)) {
+			return true
+		}
+	}
+
+	return false
+}
+func defaultClientIPReader(ctx context.Context) string {
+	remote, found := peer.FromContext(ctx)
+	if !found || remote.Addr == nil {
+		return ""
+	}
+
+	address := remote.Addr.String()
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		return host
+	}
+
+	return address
+}
+
+/* ------------------------------------------------------------------------------------------------------------------ */
+
+type Option func(*Interceptor)
+
+func WithTokenMetadataKey(value string) Option {
+	return func(this *Interceptor) { this.tokenKey = strings.ToLower(value) }
+}
+func WithClientIPReader(callback func(context.Context) string) Option {
+	return func(this *Interceptor) { this.clientIP = callback }
+}
+func WithProtectedMethods(methods ...string) Option {
+	return func(this *Interceptor) { this.protected = methods }
+}
+func WithSkippedMethods(methods ...string) Option {
+	return func(this *Interceptor) { this.skipped = methods }
+}
+
+/* ------------------------------------------------------------------------------------------------------------------ */
+
+const DefaultTokenMetadataKey = "x-recaptcha-token"
+
+const (
+	rejectedMessage    = "recaptcha verification rejected"
+	unavailableMessage = "recaptcha verification unavailable"
+	internalMessage    = "recaptcha verification misconfigured"
+)
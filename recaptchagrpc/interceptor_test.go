@@ -0,0 +1,171 @@
+package recaptchagrpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/recaptcha"
+	"github.com/smartystreets/recaptcha/recaptchatest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestInterceptorFixture(t *testing.T) {
+	gunit.Run(new(InterceptorFixture), t)
+}
+
+type InterceptorFixture struct {
+	*gunit.Fixture
+
+	verifier    *recaptchatest.Verifier
+	interceptor *Interceptor
+	ctx         context.Context
+	handled     int
+}
+
+func (this *InterceptorFixture) Setup() {
+	this.verifier = recaptchatest.NewVerifier()
+	this.interceptor = New(this.verifier)
+	this.ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(DefaultTokenMetadataKey, "token"))
+	this.ctx = peer.NewContext(this.ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("1.2.3.4"), Port: 1234}})
+}
+
+func (this *InterceptorFixture) TestAcceptedCallHandled() {
+	this.verifier.On("token").Accept()
+
+	response, err := this.unary("/signup.Service/Create")
+
+	this.So(err, should.BeNil)
+	this.So(response, should.Equal, "response")
+	this.So(this.handled, should.Equal, 1)
+	this.So(this.verifier, recaptchatest.ShouldHaveVerified, "token", "1.2.3.4")
+}
+func (this *InterceptorFixture) TestRejectedCallDenied() {
+	this.verifier.On("token").Reject()
+
+	_, err := this.unary("/signup.Service/Create")
+
+	this.So(status.Code(err), should.Equal, codes.PermissionDenied)
+	this.So(this.handled, should.Equal, 0)
+}
+func (this *InterceptorFixture) TestInvalidTokenDenied() {
+	this.verifier.On("token").Fail(recaptcha.ErrInvalidToken)
+
+	_, err := this.unary("/signup.Service/Create")
+
+	this.So(status.Code(err), should.Equal, codes.PermissionDenied)
+}
+func (this *InterceptorFixture) TestLookupFailureUnavailable() {
+	this.verifier.On("token").FailLookup()
+
+	_, err := this.unary("/signup.Service/Create")
+
+	this.So(status.Code(err), should.Equal, codes.Unavailable)
+	this.So(this.handled, should.Equal, 0)
+}
+func (this *InterceptorFixture) TestConfigurationErrorInternal() {
+	this.verifier.On("token").Fail(recaptcha.ErrServerConfig)
+
+	_, err := this.unary("/signup.Service/Create")
+
+	this.So(status.Code(err), should.Equal, codes.Internal)
+}
+func (this *InterceptorFixture) TestCanceledCallReportsContextError() {
+	this.verifier.On("token").Fail(context.Canceled)
+
+	_, err := this.unary("/signup.Service/Create")
+
+	this.So(status.Code(err), should.Equal, codes.Canceled)
+	this.So(this.handled, should.Equal, 0)
+}
+func (this *InterceptorFixture) TestExpiredDeadlineReportsContextError() {
+	this.verifier.On("token").Fail(fmt.Errorf("lookup: %w", context.DeadlineExceeded))
+
+	_, err := this.unary("/signup.Service/Create")
+
+	this.So(status.Code(err), should.Equal, codes.DeadlineExceeded)
+	this.So(this.handled, should.Equal, 0)
+}
+func (this *InterceptorFixture) TestMissingTokenVerifiedAsEmpty() {
+	this.ctx = context.Background()
+
+	_, err := this.unary("/signup.Service/Create")
+
+	this.So(status.Code(err), should.Equal, codes.PermissionDenied)
+	this.So(this.verifier, recaptchatest.ShouldHaveVerified, "", "")
+}
+func (this *InterceptorFixture) TestCustomTokenKeyAndClientIP() {
+	this.interceptor = New(this.verifier,
+		WithTokenMetadataKey("X-Captcha"),
+		WithClientIPReader(func(context.Context) string { return "5.6.7.8" }))
+	this.ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-captcha", "other"))
+	this.verifier.On("other").Accept()
+
+	_, err := this.unary("/signup.Service/Create")
+
+	this.So(err, should.BeNil)
+	this.So(this.verifier, recaptchatest.ShouldHaveVerified, "other", "5.6.7.8")
+}
+func (this *InterceptorFixture) TestOnlyProtectedMethodsVerified() {
+	this.interceptor = New(this.verifier, WithProtectedMethods("/signup.Service/", "/login.Service/Login"))
+
+	_, unprotected := this.unary("/login.Service/Logout")
+	_, protected := this.unary("/signup.Service/Create")
+
+	this.So(unprotected, should.BeNil)
+	this.So(status.Code(protected), should.Equal, codes.PermissionDenied)
+	this.So(this.verifier.Calls(), should.HaveLength, 1)
+}
+func (this *InterceptorFixture) TestSkippedMethodsNotVerified() {
+	this.interceptor = New(this.verifier, WithSkippedMethods("/grpc.health.v1.Health/"))
+
+	_, err := this.unary("/grpc.health.v1.Health/Check")
+
+	this.So(err, should.BeNil)
+	this.So(this.verifier, recaptchatest.ShouldNotHaveVerified)
+}
+func (this *InterceptorFixture) TestStreamVerified() {
+	this.verifier.On("token").FailLookup()
+	info := &grpc.StreamServerInfo{FullMethod: "/signup.Service/Watch"}
+
+	err := this.interceptor.Stream()(nil, &fakeServerStream{ctx: this.ctx}, info, this.handleStream)
+
+	this.So(status.Code(err), should.Equal, codes.Unavailable)
+	this.So(this.handled, should.Equal, 0)
+}
+func (this *InterceptorFixture) TestAcceptedStreamHandled() {
+	this.verifier.On("token").Accept()
+	info := &grpc.StreamServerInfo{FullMethod: "/signup.Service/Watch"}
+
+	err := this.interceptor.Stream()(nil, &fakeServerStream{ctx: this.ctx}, info, this.handleStream)
+
+	this.So(err, should.BeNil)
+	this.So(this.handled, should.Equal, 1)
+}
+
+func (this *InterceptorFixture) unary(method string) (interface{}, error) {
+	info := &grpc.UnaryServerInfo{FullMethod: method}
+	return this.interceptor.Unary()(this.ctx, "request", info, this.handleUnary)
+}
+func (this *InterceptorFixture) handleUnary(context.Context, interface{}) (interface{}, error) {
+	this.handled++
+	return "response", nil
+}
+func (this *InterceptorFixture) handleStream(interface{}, grpc.ServerStream) error {
+	this.handled++
+	return nil
+}
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (this *fakeServerStream) Context() context.Context { return this.ctx }
@@ -0,0 +1,49 @@
+package recaptchatest
+
+import "fmt"
+
+func ShouldHaveVerified(actual interface{}, expected ...interface{}) string {
+	verifier, ok := actual.(*Verifier)
+	if !ok {
+		return fmt.Sprintf("Expected a *recaptchatest.Verifier, got %T.", actual)
+	} else if len(expected) != 2 {
+		return "Expected a token and a client IP address."
+	}
+
+	want := Call{Token: fmt.Sprint(expected[0]), ClientIP: fmt.Sprint(expected[1])}
+	for _, call := range verifier.Calls() {
+		if call == want {
+			return success
+		}
+	}
+
+	return fmt.Sprintf("Expected a verification of %+v, but received: %+v", want, verifier.Calls())
+}
+
+func ShouldNotHaveVerified(actual interface{}, expected ...interface{}) string {
+	verifier, ok := actual.(*Verifier)
+	if !ok {
+		return fmt.Sprintf("Expected a *recaptchatest.Verifier, got %T.", actual)
+	} else if len(expected) != 0 {
+		return "Expected no arguments."
+	} else if calls := verifier.Calls(); len(calls) > 0 {
+		return fmt.Sprintf("Expected no verifications, but received: %+v", calls)
+	}
+
+	return success
+}
+
+func ShouldHaveVerifiedTimes(actual interface{}, expected ...interface{}) string {
+	verifier, ok := actual.(*Verifier)
+	if !ok {
+		return fmt.Sprintf("Expected a *recaptchatest.Verifier, got %T.", actual)
+	} else if len(expected) != 1 {
+		return "Expected a number of verifications."
+	} else if calls := verifier.Calls(); fmt.Sprint(len(calls)) != fmt.Sprint(expected[0]) {
+		return fmt.Sprintf("Expected %v verifications, but received %d: %+v", expected[0], len(calls), calls)
+	}
+
+	return success
+}
+
+const success = ""
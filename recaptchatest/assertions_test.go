@@ -0,0 +1,47 @@
+package recaptchatest
+
+import (
+	"testing"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+)
+
+func TestAssertionsFixture(t *testing.T) {
+	gunit.Run(new(AssertionsFixture), t)
+}
+
+type AssertionsFixture struct {
+	*gunit.Fixture
+
+	verifier *Verifier
+}
+
+func (this *AssertionsFixture) Setup() {
+	this.verifier = NewVerifier()
+}
+
+func (this *AssertionsFixture) TestShouldHaveVerified() {
+	_, _ = this.verifier.Verify("token", "1.2.3.4")
+
+	this.So(ShouldHaveVerified(this.verifier, "token", "1.2.3.4"), should.BeEmpty)
+	this.So(ShouldHaveVerified(this.verifier, "token", "5.6.7.8"), should.NotBeEmpty)
+	this.So(ShouldHaveVerified(this.verifier, "token"), should.NotBeEmpty)
+	this.So(ShouldHaveVerified("not a verifier", "token", "1.2.3.4"), should.NotBeEmpty)
+}
+func (this *AssertionsFixture) TestShouldNotHaveVerified() {
+	this.So(ShouldNotHaveVerified(this.verifier), should.BeEmpty)
+
+	_, _ = this.verifier.Verify("token", "1.2.3.4")
+
+	this.So(ShouldNotHaveVerified(this.verifier), should.NotBeEmpty)
+	this.So(ShouldNotHaveVerified(nil), should.NotBeEmpty)
+}
+func (this *AssertionsFixture) TestShouldHaveVerifiedTimes() {
+	_, _ = this.verifier.Verify("token", "1.2.3.4")
+	_, _ = this.verifier.Verify("token", "1.2.3.4")
+
+	this.So(ShouldHaveVerifiedTimes(this.verifier, 2), should.BeEmpty)
+	this.So(ShouldHaveVerifiedTimes(this.verifier, 1), should.NotBeEmpty)
+	this.So(ShouldHaveVerifiedTimes(this.verifier), should.NotBeEmpty)
+}
@@ -0,0 +1,85 @@
+package recaptchatest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/smartystreets/recaptcha"
+)
+
+type Script struct {
+	mutex   sync.Mutex
+	score   *float32
+	result  bool
+	err     error
+	blocked chan struct{}
+}
+
+func (this *Script) Accept() *Script {
+	return this.set(true, nil, nil)
+}
+func (this *Script) Reject() *Script {
+	return this.set(false, nil, nil)
+}
+func (this *Script) Score(value float32) *Script {
+	return this.set(false, nil, &value)
+}
+func (this *Script) FailLookup() *Script {
+	return this.Fail(recaptcha.ErrLookupFailure)
+}
+func (this *Script) Fail(err error) *Script {
+	return this.set(false, err, nil)
+}
+func (this *Script) set(result bool, err error, score *float32) *Script {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.result, this.err, this.score = result, err, score
+	return this
+}
+
+func (this *Script) Block() *Script {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.blocked == nil {
+		this.blocked = make(chan struct{})
+	}
+
+	return this
+}
+func (this *Script) Release() {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.blocked != nil {
+		close(this.blocked)
+		this.blocked = nil
+	}
+}
+func (this *Script) wait(ctx context.Context) error {
+	this.mutex.Lock()
+	blocked := this.blocked
+	this.mutex.Unlock()
+
+	if blocked == nil {
+		return nil
+	}
+
+	select {
+	case <-blocked:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (this *Script) outcome(threshold float32) (recaptcha.Result, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.score != nil {
+		return recaptcha.Result{Accepted: *this.score >= threshold, Score: *this.score}, nil
+	}
+
+	return recaptcha.Result{Accepted: this.result}, this.err
+}
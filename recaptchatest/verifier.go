@@ -0,0 +1,95 @@
+package recaptchatest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/smartystreets/recaptcha"
+)
+
+type Verifier struct {
+	mutex     sync.Mutex
+	threshold float32
+	fallback  *Script
+	scripts   map[string]*Script
+	calls     []Call
+}
+
+func NewVerifier(options ...Option) *Verifier {
+	this := &Verifier{scripts: make(map[string]*Script)}
+
+	WithThreshold(defaultThreshold)(this)
+	WithFallback(new(Script).Reject())(this)
+
+	for _, option := range options {
+		option(this)
+	}
+
+	return this
+}
+
+func (this *Verifier) On(token string) *Script {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	script := new(Script).Reject()
+	this.scripts[token] = script
+	return script
+}
+
+func (this *Verifier) Verify(token, clientIP string) (bool, error) {
+	result, err := this.VerifyContext(context.Background(), token, clientIP)
+	return result.Accepted, err
+}
+func (this *Verifier) VerifyContext(ctx context.Context, token, clientIP string) (recaptcha.Result, error) {
+	script, threshold := this.record(token, clientIP)
+
+	if err := script.wait(ctx); err != nil {
+		return recaptcha.Result{}, err
+	}
+
+	return script.outcome(threshold)
+}
+func (this *Verifier) record(token, clientIP string) (*Script, float32) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.calls = append(this.calls, Call{Token: token, ClientIP: clientIP})
+
+	if script, found := this.scripts[token]; found {
+		return script, this.threshold
+	}
+
+	return this.fallback, this.threshold
+}
+
+func (this *Verifier) Calls() []Call {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return append([]Call(nil), this.calls...)
+}
+func (this *Verifier) Reset() {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.calls = nil
+}
+
+type Call struct {
+	Token    string
+	ClientIP string
+}
+
+/* ------------------------------------------------------------------------------------------------------------------ */
+
+type Option func(*Verifier)
+
+func WithThreshold(value float32) Option {
+	return func(this *Verifier) { this.threshold = value }
+}
+func WithFallback(script *Script) Option {
+	return func(this *Verifier) { this.fallback = script }
+}
+
+const defaultThreshold = 0.5
+
+var _ recaptcha.ContextVerifier = new(Verifier)
@@ -0,0 +1,137 @@
+package recaptchatest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/recaptcha"
+)
+
+func TestVerifierFixture(t *testing.T) {
+	gunit.Run(new(VerifierFixture), t)
+}
+
+type VerifierFixture struct {
+	*gunit.Fixture
+
+	verifier *Verifier
+}
+
+func (this *VerifierFixture) Setup() {
+	this.verifier = NewVerifier()
+}
+
+func (this *VerifierFixture) TestUnscriptedTokensRejected() {
+	result, err := this.verifier.Verify("unknown", "")
+
+	this.So(result, should.BeFalse)
+	this.So(err, should.BeNil)
+}
+func (this *VerifierFixture) TestFallbackScript() {
+	this.verifier = NewVerifier(WithFallback(new(Script).Accept()))
+
+	result, err := this.verifier.Verify("unknown", "")
+
+	this.So(result, should.BeTrue)
+	this.So(err, should.BeNil)
+}
+func (this *VerifierFixture) TestScriptedOutcomes() {
+	this.verifier.On("accept").Accept()
+	this.verifier.On("reject").Reject()
+	this.verifier.On("lookup").FailLookup()
+	this.verifier.On("config").Fail(recaptcha.ErrServerConfig)
+
+	this.assertOutcome("accept", true, nil)
+	this.assertOutcome("reject", false, nil)
+	this.assertOutcome("lookup", false, recaptcha.ErrLookupFailure)
+	this.assertOutcome("config", false, recaptcha.ErrServerConfig)
+}
+func (this *VerifierFixture) TestScoreComparedToThreshold() {
+	this.verifier = NewVerifier(WithThreshold(0.7))
+	this.verifier.On("high").Score(0.7)
+	this.verifier.On("low").Score(0.6)
+
+	this.assertOutcome("high", true, nil)
+	this.assertOutcome("low", false, nil)
+}
+func (this *VerifierFixture) TestScriptsCanBeChanged() {
+	script := this.verifier.On("token").Accept()
+	script.Reject()
+
+	this.assertOutcome("token", false, nil)
+}
+func (this *VerifierFixture) TestBlockUntilReleased() {
+	script := this.verifier.On("token").Accept().Block()
+	done := make(chan bool)
+
+	go func() {
+		result, _ := this.verifier.Verify("token", "")
+		done <- result
+	}()
+
+	select {
+	case <-done:
+		this.Error("Verify returned before the script was released.")
+	case <-time.After(time.Millisecond * 10):
+	}
+
+	script.Release()
+	this.So(<-done, should.BeTrue)
+}
+func (this *VerifierFixture) TestBlockedVerificationAbandonedWithContext() {
+	this.verifier.On("token").Accept().Block()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := this.verifier.VerifyContext(ctx, "token", "")
+
+	this.So(result.Accepted, should.BeFalse)
+	this.So(err, should.Equal, context.Canceled)
+}
+func (this *VerifierFixture) TestScoreReported() {
+	this.verifier.On("token").Score(0.9)
+
+	result, err := this.verifier.VerifyContext(context.Background(), "token", "")
+
+	this.So(result, should.Resemble, recaptcha.Result{Accepted: true, Score: 0.9})
+	this.So(err, should.BeNil)
+}
+func (this *VerifierFixture) TestCallsRecorded() {
+	_, _ = this.verifier.Verify("a", "1.1.1.1")
+	_, _ = this.verifier.Verify("b", "2.2.2.2")
+
+	this.So(this.verifier.Calls(), should.Resemble, []Call{
+		{Token: "a", ClientIP: "1.1.1.1"},
+		{Token: "b", ClientIP: "2.2.2.2"},
+	})
+
+	this.verifier.Reset()
+	this.So(this.verifier.Calls(), should.BeEmpty)
+}
+func (this *VerifierFixture) TestWiredIntoHandler() {
+	this.verifier.On("good").Accept()
+	handler := recaptcha.NewHandler(this.verifier, recaptcha.WithInnerHandler(http.NotFoundHandler()))
+
+	accepted := httptest.NewRecorder()
+	handler.ServeHTTP(accepted, httptest.NewRequest(http.MethodGet, "/?g-recaptcha-response=good", nil))
+	rejected := httptest.NewRecorder()
+	handler.ServeHTTP(rejected, httptest.NewRequest(http.MethodGet, "/?g-recaptcha-response=bad", nil))
+
+	this.So(accepted.Code, should.Equal, http.StatusNotFound)
+	this.So(rejected.Code, should.Equal, http.StatusForbidden)
+	this.So(this.verifier, ShouldHaveVerified, "good", "192.0.2.1:1234")
+	this.So(this.verifier, ShouldHaveVerifiedTimes, 2)
+}
+
+func (this *VerifierFixture) assertOutcome(token string, expectedResult bool, expectedErr error) {
+	result, err := this.verifier.Verify(token, "")
+
+	this.So(result, should.Equal, expectedResult)
+	this.So(errors.Is(err, expectedErr), should.BeTrue)
+}
@@ -0,0 +1,55 @@
+package recaptcha
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+)
+
+type Result struct {
+	Accepted           bool
+	Score              float32
+	Action             string
+	Hostname           string
+	AndroidPackageName string
+	IOSBundleID        string
+	ChallengeTS        time.Time
+	ErrorCodes         []string
+	Reason             string
+	Bypass             bool
+}
+
+func (this Result) ScoreBucket() string {
+	bucket := math.Floor(this.score()*scoreBuckets) / scoreBuckets
+	return strconv.FormatFloat(math.Max(0, math.Min(1, bucket)), 'f', 1, 64)
+}
+func (this Result) score() float64 {
+	// widening 0.7 directly yields 0.699999988..., which lands in the wrong bucket
+	value, _ := strconv.ParseFloat(strconv.FormatFloat(float64(this.Score), 'g', -1, 32), 64)
+	return value
+}
+
+func verifyContext(ctx context.Context, verifier TokenVerifier, token, clientIP string) (Result, error) {
+	if contextual, ok := verifier.(ContextVerifier); ok {
+		return contextual.VerifyContext(ctx, token, clientIP)
+	}
+
+	accepted, err := verifier.Verify(token, clientIP)
+	return Result{Accepted: accepted}, err
+}
+
+const scoreBuckets = 10
+
+const (
+	ReasonMissingToken        = "missing-token"
+	ReasonScoreBelowThreshold = "score-below-threshold"
+	ReasonHostNotAllowed      = "hostname-not-allowed"
+	ReasonActionNotAllowed    = "action-not-allowed"
+	ReasonPackageNotAllowed   = "package-not-allowed"
+	ReasonBundleNotAllowed    = "bundle-not-allowed"
+	ReasonClientAllowlisted   = "client-ip-allowlisted"
+	ReasonClientDenylisted    = "client-ip-denylisted"
+	ReasonBypassToken         = "bypass-token"
+	ReasonRateLimited         = "rate-limited"
+)
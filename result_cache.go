@@ -0,0 +1,113 @@
+package recaptcha
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type ResultCache struct {
+	mutex      sync.Mutex
+	ttl        time.Duration
+	capacity   int
+	maxReuses  int
+	sameClient bool
+	entries    map[string]*list.Element
+	order      *list.List
+	now        func() time.Time
+}
+
+func NewResultCache(ttl time.Duration, capacity int, options ...CacheOption) *ResultCache {
+	if ttl <= 0 || capacity <= 0 {
+		panic(fmt.Errorf("%w: cache TTL (%s) and capacity (%d) must be positive", errBadOptionProvided, ttl, capacity))
+	}
+
+	this := &ResultCache{
+		ttl:      ttl,
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+		now:      time.Now,
+	}
+
+	WithMaxReuses(1)(this)
+	WithSameClientIP(true)(this)
+
+	for _, option := range options {
+		option(this)
+	}
+
+	return this
+}
+
+func (this *ResultCache) Load(token, clientIP string) (Result, bool) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	element, found := this.entries[hashToken(token)]
+	if !found {
+		return Result{}, false
+	}
+
+	entry := element.Value.(*cachedResult)
+	if !this.now().Before(entry.expires) {
+		this.remove(element)
+		return Result{}, false
+	} else if this.sameClient && entry.clientIP != clientIP {
+		return Result{}, false
+	} else if this.maxReuses > 0 && entry.reuses >= this.maxReuses {
+		this.remove(element)
+		return Result{}, false
+	}
+
+	entry.reuses++
+	return entry.result, true
+}
+
+func (this *ResultCache) Store(token, clientIP string, result Result) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	key := hashToken(token)
+	if element, found := this.entries[key]; found {
+		this.remove(element)
+	}
+
+	entry := &cachedResult{key: key, clientIP: clientIP, result: result, expires: this.now().Add(this.ttl)}
+	this.entries[key] = this.order.PushFront(entry)
+
+	for this.order.Len() > this.capacity {
+		this.remove(this.order.Back())
+	}
+}
+
+func (this *ResultCache) Len() int {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.order.Len()
+}
+
+func (this *ResultCache) remove(element *list.Element) {
+	this.order.Remove(element)
+	delete(this.entries, element.Value.(*cachedResult).key)
+}
+
+type cachedResult struct {
+	key      string
+	clientIP string
+	result   Result
+	expires  time.Time
+	reuses   int
+}
+
+/* ------------------------------------------------------------------------------------------------------------------ */
+
+type CacheOption func(*ResultCache)
+
+func WithMaxReuses(value int) CacheOption {
+	return func(this *ResultCache) { this.maxReuses = value }
+}
+func WithSameClientIP(value bool) CacheOption {
+	return func(this *ResultCache) { this.sameClient = value }
+}
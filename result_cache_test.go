@@ -0,0 +1,110 @@
+package recaptcha
+
+import (
+	"testing"
+	"time"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+)
+
+func TestResultCacheFixture(t *testing.T) {
+	gunit.Run(new(ResultCacheFixture), t)
+}
+
+type ResultCacheFixture struct {
+	*gunit.Fixture
+
+	now   time.Time
+	cache *ResultCache
+}
+
+func (this *ResultCacheFixture) Setup() {
+	this.now = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	this.cache = NewResultCache(time.Minute, 2)
+	this.cache.now = func() time.Time { return this.now }
+}
+
+func (this *ResultCacheFixture) TestMissWhenEmpty() {
+	_, found := this.cache.Load("token", "ip")
+
+	this.So(found, should.BeFalse)
+}
+func (this *ResultCacheFixture) TestStoredResultReturned() {
+	this.cache.Store("token", "ip", Result{Accepted: true, Score: 0.9})
+
+	result, found := this.cache.Load("token", "ip")
+
+	this.So(found, should.BeTrue)
+	this.So(result, should.Resemble, Result{Accepted: true, Score: 0.9})
+}
+func (this *ResultCacheFixture) TestExpiredResultEvicted() {
+	this.cache.Store("token", "ip", Result{Accepted: true})
+	this.now = this.now.Add(time.Minute)
+
+	_, found := this.cache.Load("token", "ip")
+
+	this.So(found, should.BeFalse)
+	this.So(this.cache.Len(), should.Equal, 0)
+}
+func (this *ResultCacheFixture) TestLeastRecentlyStoredEvictedAtCapacity() {
+	this.cache.Store("a", "ip", Result{})
+	this.cache.Store("b", "ip", Result{})
+	this.cache.Store("c", "ip", Result{})
+
+	_, foundA := this.cache.Load("a", "ip")
+	_, foundC := this.cache.Load("c", "ip")
+
+	this.So(foundA, should.BeFalse)
+	this.So(foundC, should.BeTrue)
+	this.So(this.cache.Len(), should.Equal, 2)
+}
+func (this *ResultCacheFixture) TestSameClientIPRequiredByDefault() {
+	this.cache.Store("token", "ip", Result{Accepted: true})
+
+	_, foundOther := this.cache.Load("token", "other-ip")
+	_, foundSame := this.cache.Load("token", "ip")
+
+	this.So(foundOther, should.BeFalse)
+	this.So(foundSame, should.BeTrue)
+}
+func (this *ResultCacheFixture) TestSingleReuseByDefault() {
+	this.cache.Store("token", "ip", Result{Accepted: true})
+
+	_, first := this.cache.Load("token", "ip")
+	_, second := this.cache.Load("token", "ip")
+
+	this.So(first, should.BeTrue)
+	this.So(second, should.BeFalse)
+	this.So(this.cache.Len(), should.Equal, 0)
+}
+func (this *ResultCacheFixture) TestLooserReuseOptIn() {
+	WithSameClientIP(false)(this.cache)
+	WithMaxReuses(3)(this.cache)
+	this.cache.Store("token", "ip", Result{Accepted: true})
+
+	_, first := this.cache.Load("token", "other-ip")
+	_, second := this.cache.Load("token", "another-ip")
+	_, third := this.cache.Load("token", "ip")
+	_, fourth := this.cache.Load("token", "ip")
+
+	this.So(first, should.BeTrue)
+	this.So(second, should.BeTrue)
+	this.So(third, should.BeTrue)
+	this.So(fourth, should.BeFalse)
+}
+func (this *ResultCacheFixture) TestUnlimitedReuseOptIn() {
+	WithMaxReuses(0)(this.cache)
+	this.cache.Store("token", "ip", Result{Accepted: true})
+
+	for i := 0; i < 5; i++ {
+		_, found := this.cache.Load("token", "ip")
+		this.So(found, should.BeTrue)
+	}
+}
+func (this *ResultCacheFixture) TestNonPositiveTTLOrCapacityPanics() {
+	this.So(func() { NewResultCache(0, 8) }, should.Panic)
+	this.So(func() { NewResultCache(-time.Second, 8) }, should.Panic)
+	this.So(func() { NewResultCache(time.Minute, 0) }, should.Panic)
+	this.So(func() { NewResultCache(time.Minute, -1) }, should.Panic)
+}
@@ -0,0 +1,41 @@
+package recaptcha
+
+import (
+	"context"
+	"testing"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+)
+
+func TestResultFixture(t *testing.T) {
+	gunit.Run(new(ResultFixture), t)
+}
+
+type ResultFixture struct {
+	*gunit.Fixture
+
+	verifiedToken string
+}
+
+func (this *ResultFixture) TestScoreBucket() {
+	this.So(Result{Score: 0}.ScoreBucket(), should.Equal, "0.0")
+	this.So(Result{Score: 0.05}.ScoreBucket(), should.Equal, "0.0")
+	this.So(Result{Score: 0.3}.ScoreBucket(), should.Equal, "0.3")
+	this.So(Result{Score: 0.7}.ScoreBucket(), should.Equal, "0.7")
+	this.So(Result{Score: 0.75}.ScoreBucket(), should.Equal, "0.7")
+	this.So(Result{Score: 1}.ScoreBucket(), should.Equal, "1.0")
+	this.So(Result{Score: 1.5}.ScoreBucket(), should.Equal, "1.0")
+}
+func (this *ResultFixture) TestPlainVerifierAdapted() {
+	result, err := verifyContext(context.Background(), this, "token", "ip")
+
+	this.So(result, should.Resemble, Result{Accepted: true})
+	this.So(err, should.BeNil)
+	this.So(this.verifiedToken, should.Equal, "token")
+}
+
+func (this *ResultFixture) Verify(token, _ string) (bool, error) {
+	this.verifiedToken = token
+	return true, nil
+}
@@ -0,0 +1,44 @@
+package recaptcha
+
+import "net/http"
+
+type TokenCapture struct {
+	inner http.Handler
+	token func(*http.Request) string
+}
+
+func NewTokenCapture(options ...CaptureOption) *TokenCapture {
+	this := &TokenCapture{}
+
+	WithCaptureHandler(http.NotFoundHandler())(this)
+	WithCaptureTokenReader(defaultTokenReader)(this)
+
+	for _, option := range options {
+		option(this)
+	}
+
+	return this
+}
+
+func (this *TokenCapture) Install(inner http.Handler) {
+	this.inner = inner
+}
+
+func (this *TokenCapture) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	if token := this.token(request); len(token) > 0 {
+		request = request.WithContext(ContextWithToken(request.Context(), token))
+	}
+
+	this.inner.ServeHTTP(response, request)
+}
+
+/* ------------------------------------------------------------------------------------------------------------------ */
+
+type CaptureOption func(*TokenCapture)
+
+func WithCaptureHandler(value http.Handler) CaptureOption {
+	return func(this *TokenCapture) { this.inner = value }
+}
+func WithCaptureTokenReader(callback func(*http.Request) string) CaptureOption {
+	return func(this *TokenCapture) { this.token = callback }
+}
@@ -0,0 +1,76 @@
+package recaptcha
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+	"github.com/smartystreets/recaptcha/emulator"
+)
+
+func TestTokenCaptureFixture(t *testing.T) {
+	gunit.Run(new(TokenCaptureFixture), t)
+}
+
+type TokenCaptureFixture struct {
+	*gunit.Fixture
+
+	captured string
+}
+
+func (this *TokenCaptureFixture) TestTokenStoredWithoutVerification() {
+	capture := NewTokenCapture(WithCaptureHandler(this))
+	request := httptest.NewRequest(http.MethodGet, "/?"+DefaultFormTokenName+"=token", nil)
+
+	capture.ServeHTTP(httptest.NewRecorder(), request)
+
+	this.So(this.captured, should.Equal, "token")
+}
+func (this *TokenCaptureFixture) TestCustomTokenReader() {
+	capture := NewTokenCapture(WithCaptureTokenReader(HeaderTokenReader(TokenHeader)))
+	capture.Install(this)
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set(TokenHeader, "header-token")
+
+	capture.ServeHTTP(httptest.NewRecorder(), request)
+
+	this.So(this.captured, should.Equal, "header-token")
+}
+func (this *TokenCaptureFixture) TestTokenForwardedAcrossTwoHops() {
+	siteverify := emulator.New(emulator.WithSecret("secret"))
+	upstream := httptest.NewServer(siteverify.Handler())
+	defer upstream.Close()
+
+	internal := httptest.NewServer(NewHandler(
+		NewVerifier(WithSecret(func() string { return "secret" }), WithEndpoint(upstream.URL+emulator.SiteverifyPath)),
+		WithInnerHandler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))))
+	defer internal.Close()
+
+	client := &http.Client{Transport: NewTokenTransport()}
+	frontend := httptest.NewServer(NewTokenCapture(WithCaptureHandler(http.HandlerFunc(
+		func(response http.ResponseWriter, request *http.Request) {
+			outbound, _ := http.NewRequestWithContext(request.Context(), http.MethodGet, internal.URL, nil)
+			forwarded, err := client.Do(outbound)
+			if err != nil {
+				response.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			_ = forwarded.Body.Close()
+			response.WriteHeader(forwarded.StatusCode)
+		}))))
+	defer frontend.Close()
+
+	token := siteverify.Issue(emulator.Token{Score: 0.9})
+	response, err := http.Get(frontend.URL + "/?" + DefaultFormTokenName + "=" + token)
+
+	this.So(err, should.BeNil)
+	this.So(response.StatusCode, should.Equal, http.StatusOK)
+	this.So(siteverify.Uses(token), should.Equal, 1)
+	_ = response.Body.Close()
+}
+
+func (this *TokenCaptureFixture) ServeHTTP(_ http.ResponseWriter, request *http.Request) {
+	this.captured = TokenFromContext(request.Context())
+}
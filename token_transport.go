@@ -0,0 +1,127 @@
+package recaptcha
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+)
+
+type TokenTransport struct {
+	inner    http.RoundTripper
+	location tokenLocation
+	name     string
+}
+
+func NewTokenTransport(options ...TransportOption) *TokenTransport {
+	this := &TokenTransport{}
+
+	WithInnerTransport(http.DefaultTransport)(this)
+	WithTokenInQuery(DefaultFormTokenName)(this)
+
+	for _, option := range options {
+		option(this)
+	}
+
+	return this
+}
+
+func (this *TokenTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	token := TokenFromContext(request.Context())
+	if len(token) == 0 {
+		return this.inner.RoundTrip(request)
+	}
+
+	outbound := request.Clone(request.Context())
+	if err := this.attach(outbound, token); err != nil {
+		closeBody(request)
+		return nil, err
+	}
+
+	return this.inner.RoundTrip(outbound)
+}
+func (this *TokenTransport) attach(request *http.Request, token string) error {
+	switch this.location {
+	case tokenInHeader:
+		request.Header.Set(this.name, token)
+	case tokenInForm:
+		return this.attachForm(request, token)
+	default:
+		query := request.URL.Query()
+		query.Set(this.name, token)
+		request.URL.RawQuery = query.Encode()
+	}
+
+	return nil
+}
+func (this *TokenTransport) attachForm(request *http.Request, token string) error {
+	form := url.Values{}
+
+	if request.Body != nil && request.Body != http.NoBody {
+		if mediaType, _, _ := mime.ParseMediaType(request.Header.Get(contentTypeHeader)); mediaType != defaultContentType {
+			return fmt.Errorf("%w: cannot attach token to %q request body", ErrTokenTransport, mediaType)
+		}
+
+		raw, err := io.ReadAll(request.Body)
+		_ = request.Body.Close()
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrTokenTransport, err)
+		}
+
+		if form, err = url.ParseQuery(string(raw)); err != nil {
+			return fmt.Errorf("%w: %s", ErrTokenTransport, err)
+		}
+	}
+
+	form.Set(this.name, token)
+	encoded := []byte(form.Encode())
+	request.Body = io.NopCloser(bytes.NewReader(encoded))
+	request.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(encoded)), nil }
+	request.ContentLength = int64(len(encoded))
+	request.Header.Set(contentTypeHeader, defaultContentType)
+	return nil
+}
+
+func closeBody(request *http.Request) {
+	if request.Body != nil {
+		_ = request.Body.Close()
+	}
+}
+
+func TokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(tokenContextKey{}).(string)
+	return token
+}
+func ContextWithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, tokenContextKey{}, token)
+}
+
+type tokenContextKey struct{}
+
+/* ------------------------------------------------------------------------------------------------------------------ */
+
+type TransportOption func(*TokenTransport)
+
+func WithInnerTransport(value http.RoundTripper) TransportOption {
+	return func(this *TokenTransport) { this.inner = value }
+}
+func WithTokenInQuery(name string) TransportOption {
+	return func(this *TokenTransport) { this.location, this.name = tokenInQuery, name }
+}
+func WithTokenInHeader(name string) TransportOption {
+	return func(this *TokenTransport) { this.location, this.name = tokenInHeader, name }
+}
+func WithTokenInForm(name string) TransportOption {
+	return func(this *TokenTransport) { this.location, this.name = tokenInForm, name }
+}
+
+type tokenLocation int
+
+const (
+	tokenInQuery tokenLocation = iota
+	tokenInHeader
+	tokenInForm
+)
@@ -0,0 +1,98 @@
+package recaptcha
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+)
+
+func TestTokenTransportFixture(t *testing.T) {
+	gunit.Run(new(TokenTransportFixture), t)
+}
+
+type TokenTransportFixture struct {
+	*gunit.Fixture
+
+	transport *TokenTransport
+	ctx       context.Context
+	sent      *http.Request
+	sentBody  string
+}
+
+func (this *TokenTransportFixture) Setup() {
+	this.transport = NewTokenTransport(WithInnerTransport(this))
+	this.ctx = ContextWithToken(context.Background(), "token")
+}
+
+func (this *TokenTransportFixture) TestTokenAttachedToQueryByDefault() {
+	request, _ := http.NewRequestWithContext(this.ctx, http.MethodGet, "http://internal/path?a=1", nil)
+
+	_, err := this.transport.RoundTrip(request)
+
+	this.So(err, should.BeNil)
+	this.So(this.sent.URL.Query().Get(DefaultFormTokenName), should.Equal, "token")
+	this.So(this.sent.URL.Query().Get("a"), should.Equal, "1")
+	this.So(request.URL.RawQuery, should.Equal, "a=1")
+}
+func (this *TokenTransportFixture) TestTokenAttachedToHeader() {
+	WithTokenInHeader("X-Recaptcha-Token")(this.transport)
+	request, _ := http.NewRequestWithContext(this.ctx, http.MethodGet, "http://internal/", nil)
+
+	_, _ = this.transport.RoundTrip(request)
+
+	this.So(this.sent.Header.Get("X-Recaptcha-Token"), should.Equal, "token")
+	this.So(request.Header.Get("X-Recaptcha-Token"), should.BeEmpty)
+}
+func (this *TokenTransportFixture) TestTokenAddedToFormBody() {
+	WithTokenInForm("captcha")(this.transport)
+	request, _ := http.NewRequestWithContext(this.ctx, http.MethodPost, "http://internal/", strings.NewReader("name=value"))
+	request.Header.Set(contentTypeHeader, defaultContentType+"; charset=utf-8")
+
+	_, err := this.transport.RoundTrip(request)
+
+	this.So(err, should.BeNil)
+	this.So(this.sentBody, should.Equal, "captcha=token&name=value")
+	this.So(this.sent.ContentLength, should.Equal, len(this.sentBody))
+}
+func (this *TokenTransportFixture) TestFormCreatedForEmptyBody() {
+	WithTokenInForm("captcha")(this.transport)
+	request, _ := http.NewRequestWithContext(this.ctx, http.MethodPost, "http://internal/", nil)
+
+	_, _ = this.transport.RoundTrip(request)
+
+	this.So(this.sentBody, should.Equal, "captcha=token")
+	this.So(this.sent.Header.Get(contentTypeHeader), should.Equal, defaultContentType)
+}
+func (this *TokenTransportFixture) TestFormRejectedForOtherBodies() {
+	WithTokenInForm("captcha")(this.transport)
+	request, _ := http.NewRequestWithContext(this.ctx, http.MethodPost, "http://internal/", strings.NewReader("{}"))
+	request.Header.Set(contentTypeHeader, jsonContentType)
+
+	response, err := this.transport.RoundTrip(request)
+
+	this.So(response, should.BeNil)
+	this.So(errors.Is(err, ErrTokenTransport), should.BeTrue)
+	this.So(this.sent, should.BeNil)
+}
+func (this *TokenTransportFixture) TestRequestWithoutTokenUnchanged() {
+	request, _ := http.NewRequest(http.MethodGet, "http://internal/", nil)
+
+	_, _ = this.transport.RoundTrip(request)
+
+	this.So(this.sent, should.Equal, request)
+}
+
+func (this *TokenTransportFixture) RoundTrip(request *http.Request) (*http.Response, error) {
+	this.sent = request
+	if request.Body != nil {
+		raw, _ := io.ReadAll(request.Body)
+		this.sentBody = string(raw)
+	}
+	return &http.Response{StatusCode: http.StatusOK}, nil
+}
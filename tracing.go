@@ -0,0 +1,50 @@
+package recaptcha
+
+import "context"
+
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
+
+/* ------------------------------------------------------------------------------------------------------------------ */
+
+type nopTracer struct{}
+
+func (nopTracer) Start(ctx context.Context, _ string) (context.Context, Span) { return ctx, nopSpan{} }
+
+type nopSpan struct{}
+
+func (nopSpan) SetAttribute(string, interface{}) {}
+func (nopSpan) RecordError(error)                {}
+func (nopSpan) End()                             {}
+
+/* ------------------------------------------------------------------------------------------------------------------ */
+
+func traceResult(span Span, result Result, err error) {
+	span.SetAttribute(AttributeAccepted, result.Accepted)
+	span.SetAttribute(AttributeScore, float64(result.Score))
+	span.SetAttribute(AttributeAction, result.Action)
+	span.SetAttribute(AttributeHostname, result.Hostname)
+
+	if err != nil {
+		span.RecordError(err)
+	}
+}
+
+const (
+	SpanSiteverify = "recaptcha.siteverify"
+	SpanDecision   = "recaptcha.decision"
+
+	AttributeAccepted = "recaptcha.accepted"
+	AttributeScore    = "recaptcha.score"
+	AttributeAction   = "recaptcha.action"
+	AttributeHostname = "recaptcha.hostname"
+	AttributeOutcome  = "recaptcha.outcome"
+	AttributeAttempts = "recaptcha.attempts"
+)
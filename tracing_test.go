@@ -0,0 +1,69 @@
+package recaptcha
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+)
+
+func TestTracingFixture(t *testing.T) {
+	gunit.Run(new(TracingFixture), t)
+}
+
+type TracingFixture struct {
+	*gunit.Fixture
+}
+
+func (this *TracingFixture) TestNopTracerPreservesContext() {
+	ctx := context.WithValue(context.Background(), testContextKey{}, "value")
+
+	traced, span := nopTracer{}.Start(ctx, "name")
+	span.SetAttribute("key", "value")
+	span.RecordError(errors.New(""))
+	span.End()
+
+	this.So(traced, should.Equal, ctx)
+}
+func (this *TracingFixture) TestResultAttributes() {
+	span := &recordedSpan{attributes: map[string]interface{}{}}
+	err := errors.New("")
+
+	traceResult(span, Result{Accepted: true, Score: 0.5, Action: "login", Hostname: "example.com"}, err)
+
+	this.So(span.attributes, should.Resemble, map[string]interface{}{
+		AttributeAccepted: true,
+		AttributeScore:    0.5,
+		AttributeAction:   "login",
+		AttributeHostname: "example.com",
+	})
+	this.So(span.errors, should.Resemble, []error{err})
+}
+
+/* ------------------------------------------------------------------------------------------------------------------ */
+
+type recordingTracer struct {
+	spans []*recordedSpan
+}
+
+func (this *recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := &recordedSpan{name: name, parent: ctx, attributes: map[string]interface{}{}}
+	this.spans = append(this.spans, span)
+	return context.WithValue(ctx, recordedSpanKey{}, span), span
+}
+
+type recordedSpanKey struct{}
+
+type recordedSpan struct {
+	name       string
+	parent     context.Context
+	attributes map[string]interface{}
+	errors     []error
+	ended      bool
+}
+
+func (this *recordedSpan) SetAttribute(key string, value interface{}) { this.attributes[key] = value }
+func (this *recordedSpan) RecordError(err error)                      { this.errors = append(this.errors, err) }
+func (this *recordedSpan) End()                                       { this.ended = true }
@@ -0,0 +1,57 @@
+package recaptcha
+
+import "strings"
+
+type VerificationError struct {
+	Codes []string
+}
+
+func (this *VerificationError) Error() string {
+	return "the verification endpoint reported: " + strings.Join(this.Codes, ", ")
+}
+
+func (this *VerificationError) Unwrap() error {
+	if this.IsClientError() {
+		return ErrInvalidToken
+	}
+
+	return ErrServerConfig
+}
+
+func (this *VerificationError) IsClientError() bool {
+	for _, code := range this.Codes {
+		if _, found := clientErrorCodes[code]; !found {
+			return false
+		}
+	}
+
+	return len(this.Codes) > 0
+}
+
+func (this *VerificationError) Has(code string) bool {
+	for _, item := range this.Codes {
+		if item == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+/* ------------------------------------------------------------------------------------------------------------------ */
+
+// Error Code Reference: https://developers.google.com/recaptcha/docs/verify
+const (
+	ErrorCodeMissingSecret   = "missing-input-secret"
+	ErrorCodeInvalidSecret   = "invalid-input-secret"
+	ErrorCodeMissingResponse = "missing-input-response"
+	ErrorCodeInvalidResponse = "invalid-input-response"
+	ErrorCodeBadRequest      = "bad-request"
+	ErrorCodeDuplicate       = "timeout-or-duplicate"
+)
+
+var clientErrorCodes = map[string]struct{}{
+	ErrorCodeMissingResponse: {},
+	ErrorCodeInvalidResponse: {},
+	ErrorCodeDuplicate:       {},
+}
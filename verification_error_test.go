@@ -0,0 +1,59 @@
+package recaptcha
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+)
+
+func TestVerificationErrorFixture(t *testing.T) {
+	gunit.Run(new(VerificationErrorFixture), t)
+}
+
+type VerificationErrorFixture struct {
+	*gunit.Fixture
+}
+
+func (this *VerificationErrorFixture) TestClientErrorCodes() {
+	for _, code := range []string{ErrorCodeMissingResponse, ErrorCodeInvalidResponse, ErrorCodeDuplicate} {
+		err := &VerificationError{Codes: []string{code}}
+
+		this.So(err.IsClientError(), should.BeTrue)
+		this.So(err, should.Wrap, ErrInvalidToken)
+		this.So(errors.Is(err, ErrServerConfig), should.BeFalse)
+	}
+}
+func (this *VerificationErrorFixture) TestConfigurationErrorCodes() {
+	for _, code := range []string{ErrorCodeMissingSecret, ErrorCodeInvalidSecret, ErrorCodeBadRequest, "unknown-code"} {
+		err := &VerificationError{Codes: []string{code}}
+
+		this.So(err.IsClientError(), should.BeFalse)
+		this.So(err, should.Wrap, ErrServerConfig)
+		this.So(errors.Is(err, ErrInvalidToken), should.BeFalse)
+	}
+}
+func (this *VerificationErrorFixture) TestConfigurationErrorTakesPrecedence() {
+	err := &VerificationError{Codes: []string{ErrorCodeInvalidResponse, ErrorCodeInvalidSecret}}
+
+	this.So(err, should.Wrap, ErrServerConfig)
+}
+func (this *VerificationErrorFixture) TestHasCode() {
+	err := &VerificationError{Codes: []string{ErrorCodeInvalidResponse, ErrorCodeInvalidSecret}}
+
+	this.So(err.Has(ErrorCodeInvalidSecret), should.BeTrue)
+	this.So(err.Has(ErrorCodeDuplicate), should.BeFalse)
+}
+func (this *VerificationErrorFixture) TestMessageListsCodes() {
+	err := &VerificationError{Codes: []string{ErrorCodeInvalidResponse, ErrorCodeInvalidSecret}}
+
+	this.So(err.Error(), should.EndWith, "invalid-input-response, invalid-input-secret")
+}
+func (this *VerificationErrorFixture) TestErrorsAs() {
+	var err error = &VerificationError{Codes: []string{ErrorCodeDuplicate}}
+	var typed *VerificationError
+
+	this.So(errors.As(err, &typed), should.BeTrue)
+	this.So(typed.Codes, should.Resemble, []string{ErrorCodeDuplicate})
+}
@@ -0,0 +1,91 @@
+package recaptcha
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/url"
+)
+
+type Widget struct {
+	siteKey   string
+	provider  string
+	actions   map[string]struct{}
+	tokenName string
+}
+
+func NewWidget(verifier *DefaultVerifier) *Widget {
+	return &Widget{
+		siteKey:   verifier.siteKey,
+		provider:  verifier.provider,
+		actions:   verifier.actions,
+		tokenName: DefaultFormTokenName,
+	}
+}
+
+func (this *Widget) Funcs() template.FuncMap {
+	return template.FuncMap{
+		"recaptchaScript":    this.Script,
+		"recaptchaCheckbox":  this.Checkbox,
+		"recaptchaInvisible": this.Invisible,
+		"recaptchaExecute":   this.Execute,
+		"recaptchaInput":     this.Input,
+	}
+}
+
+func (this *Widget) Script(nonce string) template.HTML {
+	return this.loader(this.provider+scriptPath, nonce)
+}
+func (this *Widget) Checkbox() template.HTML {
+	return template.HTML(fmt.Sprintf(`<div class="g-recaptcha" data-sitekey="%s"></div>`,
+		template.HTMLEscapeString(this.siteKey)))
+}
+func (this *Widget) Invisible(callback string) template.HTML {
+	return template.HTML(fmt.Sprintf(`<div class="g-recaptcha" data-sitekey="%s" data-size="invisible" data-callback="%s"></div>`,
+		template.HTMLEscapeString(this.siteKey), template.HTMLEscapeString(callback)))
+}
+func (this *Widget) Input(action string) (template.HTML, error) {
+	if !isValueAllowed(action, this.actions) {
+		return "", fmt.Errorf("%w: %q", errWidgetActionNotAllowed, action)
+	}
+
+	return template.HTML(fmt.Sprintf(`<input type="hidden" name="%s" data-recaptcha-action="%s">`,
+		template.HTMLEscapeString(this.tokenName), template.HTMLEscapeString(action))), nil
+}
+func (this *Widget) Execute(action, nonce string) (template.HTML, error) {
+	if !isValueAllowed(action, this.actions) {
+		return "", fmt.Errorf("%w: %q", errWidgetActionNotAllowed, action)
+	}
+
+	source := this.provider + scriptPath + "?render=" + url.QueryEscape(this.siteKey)
+	snippet := fmt.Sprintf(executeSnippet,
+		template.JSEscapeString(action), template.JSEscapeString(this.siteKey), template.JSEscapeString(action))
+
+	return this.loader(source, nonce) + template.HTML(fmt.Sprintf(`<script nonce="%s">%s</script>`,
+		template.HTMLEscapeString(nonce), snippet)), nil
+}
+func (this *Widget) loader(source, nonce string) template.HTML {
+	return template.HTML(fmt.Sprintf(`<script src="%s" nonce="%s" async defer></script>`,
+		template.HTMLEscapeString(source), template.HTMLEscapeString(nonce)))
+}
+
+/* ------------------------------------------------------------------------------------------------------------------ */
+
+const (
+	ProviderGoogle       = "https://www.google.com"
+	ProviderRecaptchaNet = "https://www.recaptcha.net"
+
+	scriptPath = "/recaptcha/api.js"
+
+	// Tokens expire two minutes after execute, so the token is requested when the form is submitted.
+	executeSnippet = `grecaptcha.ready(function(){` +
+		`document.querySelectorAll('input[data-recaptcha-action="%s"]').forEach(function(input){` +
+		`if(!input.form){return;}` +
+		`input.form.addEventListener('submit',function(event){` +
+		`if(input.value){return;}` +
+		`event.preventDefault();` +
+		`grecaptcha.execute('%s',{action:'%s'}).then(function(token){input.value=token;input.form.submit();});` +
+		`});});});`
+)
+
+var errWidgetActionNotAllowed = errors.New("the widget action is not among the verifier's allowed actions")
@@ -0,0 +1,77 @@
+package recaptcha
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"testing"
+
+	"github.com/smartystreets/assertions/should"
+	"github.com/smartystreets/gunit"
+)
+
+func TestWidgetFixture(t *testing.T) {
+	gunit.Run(new(WidgetFixture), t)
+}
+
+type WidgetFixture struct {
+	*gunit.Fixture
+
+	widget *Widget
+}
+
+func (this *WidgetFixture) Setup() {
+	this.widget = NewWidget(NewVerifier(WithSiteKey("site-key"), WithAllowedActions("login")))
+}
+
+func (this *WidgetFixture) TestScript() {
+	this.So(this.render(`{{ recaptchaScript "abc" }}`), should.Equal,
+		`<script src="https://www.google.com/recaptcha/api.js" nonce="abc" async defer></script>`)
+}
+func (this *WidgetFixture) TestScriptFromConfiguredProvider() {
+	this.widget = NewWidget(NewVerifier(WithWidgetProvider(ProviderRecaptchaNet + "/")))
+
+	this.So(this.render(`{{ recaptchaScript "" }}`), should.StartWith,
+		`<script src="https://www.recaptcha.net/recaptcha/api.js"`)
+}
+func (this *WidgetFixture) TestCheckbox() {
+	this.So(this.render(`{{ recaptchaCheckbox }}`), should.Equal,
+		`<div class="g-recaptcha" data-sitekey="site-key"></div>`)
+}
+func (this *WidgetFixture) TestInvisible() {
+	this.So(this.render(`{{ recaptchaInvisible "onSubmit" }}`), should.Equal,
+		`<div class="g-recaptcha" data-sitekey="site-key" data-size="invisible" data-callback="onSubmit"></div>`)
+}
+func (this *WidgetFixture) TestInput() {
+	this.So(this.render(`{{ recaptchaInput "login" }}`), should.Equal,
+		`<input type="hidden" name="g-recaptcha-response" data-recaptcha-action="login">`)
+}
+func (this *WidgetFixture) TestExecute() {
+	rendered := this.render(`{{ recaptchaExecute "login" "abc" }}`)
+
+	this.So(rendered, should.StartWith,
+		`<script src="https://www.google.com/recaptcha/api.js?render=site-key" nonce="abc" async defer></script><script nonce="abc">`)
+	this.So(rendered, should.ContainSubstring, `input[data-recaptcha-action="login"]`)
+	this.So(rendered, should.ContainSubstring, `grecaptcha.execute('site-key',{action:'login'})`)
+}
+func (this *WidgetFixture) TestValuesEscaped() {
+	this.widget = NewWidget(NewVerifier(WithSiteKey(`"><script>`)))
+
+	this.So(this.render(`{{ recaptchaCheckbox }}`), should.Equal,
+		`<div class="g-recaptcha" data-sitekey="&#34;&gt;&lt;script&gt;"></div>`)
+	this.So(this.render(`{{ recaptchaExecute "login" "" }}`), should.NotContainSubstring, `'"><script>'`)
+}
+func (this *WidgetFixture) TestActionOutsideAllowedActionsRejected() {
+	_, inputErr := this.widget.Input("signup")
+	_, executeErr := this.widget.Execute("signup", "abc")
+
+	this.So(errors.Is(inputErr, errWidgetActionNotAllowed), should.BeTrue)
+	this.So(errors.Is(executeErr, errWidgetActionNotAllowed), should.BeTrue)
+}
+
+func (this *WidgetFixture) render(source string) string {
+	buffer := bytes.NewBuffer(nil)
+	err := template.Must(template.New("").Funcs(this.widget.Funcs()).Parse(source)).Execute(buffer, nil)
+	this.So(err, should.BeNil)
+	return buffer.String()
+}